@@ -2,52 +2,114 @@ package authenticator
 
 import (
 	"fmt"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/luhring/awsmfa/credentials"
-	"github.com/luhring/awsmfa/credentials_file"
 	"github.com/luhring/awsmfa/environment"
 	"github.com/luhring/awsmfa/file_coordinator"
+	"io"
 	"os"
+	"time"
 )
 
-const defaultSessionDurationInSeconds = 21600 // 6 hours
+const (
+	DefaultSessionDuration     = 6 * time.Hour
+	DefaultRoleSessionDuration = 1 * time.Hour
+)
 
 type Authenticator struct {
-	stsClient       *sts.STS
+	stsClient       stsiface.STSAPI
+	iamClient       iamiface.IAMAPI // used to discover MFA devices, may be nil
 	fileCoordinator *file_coordinator.Coordinator
+
+	mfaDeviceSerialNumber string // if empty, the MFA device is discovered
+
+	promptInput  io.Reader
+	promptOutput io.Writer
 }
 
-func New(stsClient *sts.STS, fileCoordinator *file_coordinator.Coordinator) (*Authenticator, error) {
+func New(stsClient stsiface.STSAPI, iamClient iamiface.IAMAPI, fileCoordinator *file_coordinator.Coordinator) (*Authenticator, error) {
 	return &Authenticator{
-		stsClient,
-		fileCoordinator,
+		stsClient:       stsClient,
+		iamClient:       iamClient,
+		fileCoordinator: fileCoordinator,
+		promptInput:     os.Stdin,
+		promptOutput:    os.Stderr,
 	}, nil
 }
 
-func (a *Authenticator) AuthenticateUsingMFA(mfaToken string) error {
-	newCredentials, err := a.requestNewTemporaryCredentials(mfaToken, defaultSessionDurationInSeconds)
+// UseMFADevice sets the serial number (or ARN, for virtual devices) of the MFA device, which skips MFA device discovery.
+func (a *Authenticator) UseMFADevice(serialNumber string) {
+	a.mfaDeviceSerialNumber = serialNumber
+}
+
+// UsePrompt sets where the user is prompted for input, such as when selecting one of several MFA devices.
+func (a *Authenticator) UsePrompt(input io.Reader, output io.Writer) {
+	a.promptInput = input
+	a.promptOutput = output
+}
+
+// RequestCredentials obtains temporary credentials using the strategy, without saving them or printing any messages.
+func (a *Authenticator) RequestCredentials(strategy Strategy, mfaToken string, sessionDuration time.Duration) (*credentials.Credentials, error) {
+	err := ValidateSessionDuration(sessionDuration, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.requestNewTemporaryCredentials(strategy, mfaToken, int64(sessionDuration/time.Second))
+}
+
+// AuthenticateUsingMFA obtains temporary credentials using the strategy and saves them to the target profile.
+// If roleChain is non-nil, its roles are then assumed in order and their credentials are saved to their own profiles.
+// Once all credentials are saved, the file coordinator's transaction (if one is in progress) is committed.
+func (a *Authenticator) AuthenticateUsingMFA(strategy Strategy, mfaToken string, sessionDuration time.Duration, roleChain *RoleChain) error {
+	err := ValidateSessionDuration(sessionDuration, strategy)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Multi-factor authentication was successful")
+	err = ValidateRoleChain(roleChain, strategy, a.fileCoordinator.ProfileNamesInUse()...)
+	if err != nil {
+		return err
+	}
 
-	newCredentialsFile, err := credentials_file.NewFromCredentials(
-		newCredentials,
-		a.fileCoordinator.SelectedProfileName,
-		a.fileCoordinator.Env.PathToCredentialsFile(),
-	)
+	newCredentials, err := a.requestNewTemporaryCredentials(strategy, mfaToken, int64(sessionDuration/time.Second))
 	if err != nil {
 		return err
 	}
 
-	err = newCredentialsFile.Save()
+	fmt.Printf("Multi-factor authentication was successful, obtained %s\n", strategy.Description())
+
+	sources := SessionSources(strategy, roleChain, a.fileCoordinator.SelectedProfileName, a.fileCoordinator.TargetProfileName)
+	newCredentials.Source = sources[a.fileCoordinator.TargetProfileName]
+
+	err = a.saveCredentials(newCredentials, a.fileCoordinator.TargetProfileName)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Saved new session credentials to credentials file")
+	if roleChain != nil && len(roleChain.Links) > 0 {
+		linkCredentials, err := assumeRoleChain(roleChain, newCredentials)
+		if err != nil {
+			return err
+		}
+
+		for i, link := range roleChain.Links {
+			fmt.Printf("Obtained %s\n", link.Role.Description())
+
+			linkCredentials[i].Source = sources[link.ProfileName]
+
+			err = a.saveCredentials(linkCredentials[i], link.ProfileName)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = a.fileCoordinator.Commit()
+	if err != nil {
+		return err
+	}
 
 	if environment.WillEnvironmentVariablesPreemptUseOfCredentialsFile() {
 		_, _ = fmt.Fprintf(os.Stderr, "\nWARNING: Because you have the environment variable '%s' set, most AWS tools will use the credentials from your environment variables and not from your credentials file, which is where we just saved your new session credentials.\n\nYou might receive 'Access Denied' errors when performing actions that require MFA until you remove your AWS environment variables.\n", environment.NameOfVariableForAccessKeyID)
@@ -60,31 +122,27 @@ func (a *Authenticator) AuthenticateUsingMFA(mfaToken string) error {
 	return nil
 }
 
-func (a *Authenticator) requestNewTemporaryCredentials(mfaToken string, sessionDurationInSeconds int64) (*credentials.Credentials, error) {
-	serialNumber, err := a.computeMFADeviceSerialNumber()
+func (a *Authenticator) saveCredentials(c *credentials.Credentials, profileName string) error {
+	err := a.fileCoordinator.SaveCredentials(c, profileName)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	result, err := a.getSessionToken(mfaToken, serialNumber, sessionDurationInSeconds)
+	fmt.Printf("Saved new session credentials to '%s' profile in credentials file\n", profileName)
+
+	return nil
+}
 
+func (a *Authenticator) requestNewTemporaryCredentials(strategy Strategy, mfaToken string, sessionDurationInSeconds int64) (*credentials.Credentials, error) {
+	serialNumber, err := a.findMFADeviceSerialNumber()
 	if err != nil {
 		return nil, err
 	}
 
-	return credentials.New(
-		*result.Credentials.AccessKeyId,
-		*result.Credentials.SecretAccessKey,
-		*result.Credentials.SessionToken,
-	), nil
-}
-
-func (a *Authenticator) getSessionToken(mfaToken, serialNumber string, sessionDurationInSeconds int64) (*sts.GetSessionTokenOutput, error) {
-	input := &sts.GetSessionTokenInput{
-		DurationSeconds: aws.Int64(sessionDurationInSeconds),
-		SerialNumber:    aws.String(serialNumber),
-		TokenCode:       aws.String(mfaToken),
+	c, err := strategy.requestCredentials(a.stsClient, mfaToken, serialNumber, sessionDurationInSeconds)
+	if err != nil {
+		return nil, classifyAWSError(err)
 	}
 
-	return a.stsClient.GetSessionToken(input)
+	return c, nil
 }
@@ -1,6 +1,7 @@
 package authenticator
 
 import (
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/luhring/awsmfa/file_coordinator"
 	"testing"
@@ -8,9 +9,10 @@ import (
 
 func TestNew(t *testing.T) {
 	stsClient := &sts.STS{}
+	iamClient := &iam.IAM{}
 	fileCoordinator := &file_coordinator.Coordinator{}
 
-	auth, err := New(stsClient, fileCoordinator)
+	auth, err := New(stsClient, iamClient, fileCoordinator)
 
 	if err != nil {
 		t.Error("New should never return a non-nil error")
@@ -24,6 +26,10 @@ func TestNew(t *testing.T) {
 		t.Error("new authenticator object had incorrect reference for stsClient")
 	}
 
+	if auth.iamClient != iamClient {
+		t.Error("new authenticator object had incorrect reference for iamClient")
+	}
+
 	if auth.fileCoordinator != fileCoordinator {
 		t.Error("new authenticator object had incorrect reference for fileCoordinator")
 	}
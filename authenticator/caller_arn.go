@@ -0,0 +1,104 @@
+package authenticator
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"strings"
+)
+
+// Types of identities that can call STS, as they appear in the resource section of the caller's ARN
+const (
+	identityTypeUser          = "user"
+	identityTypeRoot          = "root"
+	identityTypeAssumedRole   = "assumed-role"
+	identityTypeFederatedUser = "federated-user"
+)
+
+// Name that AWS gives to the virtual MFA device of an account's root user
+const rootVirtualMFADeviceName = "root-account-mfa-device"
+
+// callerARN is the parsed ARN of the identity returned by sts:GetCallerIdentity.
+type callerARN struct {
+	Partition    string
+	AccountID    string
+	IdentityType string
+	Path         string // IAM path of a user (e.g. "/division/team/"), or "/" if the user has no path
+	Name         string // name of the user, role or federated user; empty for the root user
+	SessionName  string // session name of an assumed role
+}
+
+// parseCallerARN parses ARNs such as:
+//
+// arn:aws:iam::123456789012:user/alice
+// arn:aws:iam::123456789012:user/division/team/alice
+// arn:aws:iam::123456789012:root
+// arn:aws:sts::123456789012:assumed-role/Admin/alice
+// arn:aws:sts::123456789012:federated-user/alice
+func parseCallerARN(value string) (*callerARN, error) {
+	parsed, err := arn.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse caller ARN '%s': %s", value, err.Error())
+	}
+
+	c := &callerARN{
+		Partition: parsed.Partition,
+		AccountID: parsed.AccountID,
+	}
+
+	if len(c.Partition) == 0 || len(c.AccountID) == 0 {
+		return nil, fmt.Errorf("unable to parse caller ARN '%s': missing partition or account ID", value)
+	}
+
+	resourceParts := strings.Split(parsed.Resource, "/")
+	c.IdentityType = resourceParts[0]
+
+	switch c.IdentityType {
+	case identityTypeRoot:
+		if len(resourceParts) != 1 {
+			return nil, fmt.Errorf("unable to parse caller ARN '%s': unexpected resource '%s'", value, parsed.Resource)
+		}
+	case identityTypeUser:
+		if len(resourceParts) < 2 || len(resourceParts[len(resourceParts)-1]) == 0 {
+			return nil, fmt.Errorf("unable to parse caller ARN '%s': missing user name", value)
+		}
+
+		c.Name = resourceParts[len(resourceParts)-1]
+		c.Path = "/" + strings.Join(resourceParts[1:len(resourceParts)-1], "/")
+
+		if len(resourceParts) > 2 {
+			c.Path += "/"
+		}
+	case identityTypeAssumedRole:
+		if len(resourceParts) != 3 {
+			return nil, fmt.Errorf("unable to parse caller ARN '%s': expected role name and session name", value)
+		}
+
+		c.Name = resourceParts[1]
+		c.SessionName = resourceParts[2]
+	case identityTypeFederatedUser:
+		if len(resourceParts) != 2 {
+			return nil, fmt.Errorf("unable to parse caller ARN '%s': missing federated user name", value)
+		}
+
+		c.Name = resourceParts[1]
+	default:
+		return nil, fmt.Errorf("unable to parse caller ARN '%s': unrecognized identity type '%s'", value, c.IdentityType)
+	}
+
+	return c, nil
+}
+
+// virtualMFADeviceName returns the name that the identity's virtual MFA device is assumed to have.
+// Only IAM users and root users can own MFA devices.
+func (c *callerARN) virtualMFADeviceName() (string, error) {
+	switch c.IdentityType {
+	case identityTypeUser:
+		return c.Name, nil
+	case identityTypeRoot:
+		return rootVirtualMFADeviceName, nil
+	case identityTypeAssumedRole:
+		return "", &MFADeviceNotFoundError{fmt.Errorf("you're authenticated as a session of role '%s', which can't own an MFA device -- use the long-term credentials of an IAM user", c.Name)}
+	default:
+		return "", &MFADeviceNotFoundError{fmt.Errorf("you're authenticated as %s '%s', which can't own an MFA device -- use the long-term credentials of an IAM user", c.IdentityType, c.Name)}
+	}
+}
@@ -0,0 +1,101 @@
+package authenticator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCallerARN(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput *callerARN
+	}{
+		{
+			input:          "arn:aws:iam::123123123123:user/tony.stark",
+			expectedOutput: &callerARN{Partition: "aws", AccountID: "123123123123", IdentityType: "user", Path: "/", Name: "tony.stark"},
+		},
+		{
+			input:          "arn:aws:iam::123123123123:user/division/team/alice",
+			expectedOutput: &callerARN{Partition: "aws", AccountID: "123123123123", IdentityType: "user", Path: "/division/team/", Name: "alice"},
+		},
+		{
+			input:          "arn:aws-us-gov:iam::123123123123:user/alice",
+			expectedOutput: &callerARN{Partition: "aws-us-gov", AccountID: "123123123123", IdentityType: "user", Path: "/", Name: "alice"},
+		},
+		{
+			input:          "arn:aws-cn:iam::123123123123:root",
+			expectedOutput: &callerARN{Partition: "aws-cn", AccountID: "123123123123", IdentityType: "root"},
+		},
+		{
+			input:          "arn:aws:sts::123123123123:assumed-role/Admin/alice",
+			expectedOutput: &callerARN{Partition: "aws", AccountID: "123123123123", IdentityType: "assumed-role", Name: "Admin", SessionName: "alice"},
+		},
+		{
+			input:          "arn:aws:sts::123123123123:federated-user/alice",
+			expectedOutput: &callerARN{Partition: "aws", AccountID: "123123123123", IdentityType: "federated-user", Name: "alice"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		output, err := parseCallerARN(testCase.input)
+
+		if err != nil {
+			t.Errorf("unexpected error: %v -- input was '%s'", err, testCase.input)
+			continue
+		}
+
+		if false == reflect.DeepEqual(output, testCase.expectedOutput) {
+			t.Errorf("expected %+v but got %+v -- input was '%s'", testCase.expectedOutput, output, testCase.input)
+		}
+	}
+}
+
+func TestParseCallerARNWithInvalidARNs(t *testing.T) {
+	testCases := []string{
+		"",
+		"tony.stark",
+		"arn:aws:iam::123123123123:user",
+		"arn:aws:iam::123123123123:user/",
+		"arn:aws:iam::123123123123:group/admins",
+		"arn:aws:sts::123123123123:assumed-role/Admin",
+		"arn:aws:iam:::user/alice",
+	}
+
+	for _, input := range testCases {
+		_, err := parseCallerARN(input)
+
+		if err == nil {
+			t.Errorf("expected an error -- input was '%s'", input)
+		}
+	}
+}
+
+func TestVirtualMFADeviceName(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput string
+		expectError    bool
+	}{
+		{"arn:aws:iam::123123123123:user/division/team/alice", "alice", false},
+		{"arn:aws:iam::123123123123:root", "root-account-mfa-device", false},
+		{"arn:aws:sts::123123123123:assumed-role/Admin/alice", "", true},
+		{"arn:aws:sts::123123123123:federated-user/alice", "", true},
+	}
+
+	for _, testCase := range testCases {
+		c, err := parseCallerARN(testCase.input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		output, err := c.virtualMFADeviceName()
+
+		if (err != nil) != testCase.expectError {
+			t.Errorf("expected error: %v, but got: %v -- input was '%s'", testCase.expectError, err, testCase.input)
+		}
+
+		if output != testCase.expectedOutput {
+			t.Errorf("expected '%s' but got '%s' -- input was '%s'", testCase.expectedOutput, output, testCase.input)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package authenticator
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"strings"
+)
+
+// Process exit codes for each type of error, so that scripts can react to specific failures.
+// Exit code 1 is used for all other errors.
+const (
+	ExitCodeInvalidCredentials = 3
+	ExitCodeMFADeviceNotFound  = 4
+	ExitCodeTokenRejected      = 5
+	ExitCodeThrottled          = 6
+	ExitCodeNetwork            = 7
+)
+
+// Error is an error with a dedicated process exit code.
+type Error interface {
+	error
+	ExitCode() int
+}
+
+// InvalidCredentialsError indicates that AWS rejected (or couldn't find) the long-term credentials used to call STS.
+type InvalidCredentialsError struct {
+	Cause error
+}
+
+func (e *InvalidCredentialsError) Error() string {
+	return fmt.Sprintf("your long-term AWS credentials are missing, invalid or expired -- check the access key in your credentials file or environment variables (%s)", causeMessage(e.Cause))
+}
+
+func (e *InvalidCredentialsError) ExitCode() int {
+	return ExitCodeInvalidCredentials
+}
+
+// MFADeviceNotFoundError indicates that the MFA device couldn't be found or doesn't belong to the caller.
+type MFADeviceNotFoundError struct {
+	Cause error
+}
+
+func (e *MFADeviceNotFoundError) Error() string {
+	return fmt.Sprintf("unable to find your MFA device -- specify its serial number using --serial-number or the 'mfa_serial' setting in your config file (%s)", causeMessage(e.Cause))
+}
+
+func (e *MFADeviceNotFoundError) ExitCode() int {
+	return ExitCodeMFADeviceNotFound
+}
+
+// TokenRejectedError indicates that AWS didn't accept the MFA token.
+type TokenRejectedError struct {
+	Cause error
+}
+
+func (e *TokenRejectedError) Error() string {
+	return fmt.Sprintf("AWS rejected the MFA token -- use the currently displayed token, and wait for a new one if you've already used it (%s)", causeMessage(e.Cause))
+}
+
+func (e *TokenRejectedError) ExitCode() int {
+	return ExitCodeTokenRejected
+}
+
+// ThrottledError indicates that AWS is throttling requests.
+type ThrottledError struct {
+	Cause error
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("AWS is throttling requests -- wait a moment and try again (%s)", causeMessage(e.Cause))
+}
+
+func (e *ThrottledError) ExitCode() int {
+	return ExitCodeThrottled
+}
+
+// NetworkError indicates that AWS couldn't be reached.
+type NetworkError struct {
+	Cause error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("unable to reach AWS -- check your network connection (%s)", causeMessage(e.Cause))
+}
+
+func (e *NetworkError) ExitCode() int {
+	return ExitCodeNetwork
+}
+
+// classifyAWSError converts errors returned by the AWS SDK into one of the error types above.
+// Errors that don't match any of these types are returned unchanged.
+func classifyAWSError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, isAlreadyClassified := err.(Error); isAlreadyClassified {
+		return err
+	}
+
+	awsErr, ok := err.(awserr.Error)
+	if false == ok {
+		return err
+	}
+
+	message := strings.ToLower(awsErr.Message())
+
+	switch awsErr.Code() {
+	case "InvalidClientTokenId", "SignatureDoesNotMatch", "ExpiredToken", "ExpiredTokenException", "UnrecognizedClientException", "IncompleteSignature", "NoCredentialProviders":
+		return &InvalidCredentialsError{err}
+	case "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequestsException":
+		return &ThrottledError{err}
+	case "RequestError", request.ErrCodeResponseTimeout, request.CanceledErrorCode:
+		return &NetworkError{err}
+	case "AccessDenied":
+		// e.g. "MultiFactorAuthentication failed, unable to validate MFA code. Please verify your MFA serial number is valid and associated with this user."
+		if strings.Contains(message, "serial number") {
+			return &MFADeviceNotFoundError{err}
+		}
+
+		if strings.Contains(message, "multifactorauthentication") {
+			return &TokenRejectedError{err}
+		}
+	case "ValidationError":
+		if strings.Contains(message, "serialnumber") {
+			return &MFADeviceNotFoundError{err}
+		}
+
+		if strings.Contains(message, "tokencode") {
+			return &TokenRejectedError{err}
+		}
+	}
+
+	return err
+}
+
+func causeMessage(err error) string {
+	if err == nil {
+		return "no further details"
+	}
+
+	if awsErr, ok := err.(awserr.Error); ok {
+		if awsErr.OrigErr() != nil {
+			return fmt.Sprintf("%s: %s: %s", awsErr.Code(), awsErr.Message(), awsErr.OrigErr().Error())
+		}
+
+		return fmt.Sprintf("%s: %s", awsErr.Code(), awsErr.Message())
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,92 @@
+package authenticator
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"reflect"
+	"testing"
+)
+
+func TestClassifyAWSError(t *testing.T) {
+	testCases := []struct {
+		err              error
+		expectedType     error
+		expectedExitCode int
+	}{
+		{
+			err:              awserr.New("InvalidClientTokenId", "The security token included in the request is invalid.", nil),
+			expectedType:     &InvalidCredentialsError{},
+			expectedExitCode: ExitCodeInvalidCredentials,
+		},
+		{
+			err:              awserr.New("ExpiredToken", "The security token included in the request is expired", nil),
+			expectedType:     &InvalidCredentialsError{},
+			expectedExitCode: ExitCodeInvalidCredentials,
+		},
+		{
+			err:              awserr.New("AccessDenied", "MultiFactorAuthentication failed with invalid MFA one time pass code.", nil),
+			expectedType:     &TokenRejectedError{},
+			expectedExitCode: ExitCodeTokenRejected,
+		},
+		{
+			err:              awserr.New("AccessDenied", "MultiFactorAuthentication failed, unable to validate MFA code. Please verify your MFA serial number is valid and associated with this user.", nil),
+			expectedType:     &MFADeviceNotFoundError{},
+			expectedExitCode: ExitCodeMFADeviceNotFound,
+		},
+		{
+			err:              awserr.New("ValidationError", "1 validation error detected: Value '' at 'serialNumber' failed to satisfy constraint", nil),
+			expectedType:     &MFADeviceNotFoundError{},
+			expectedExitCode: ExitCodeMFADeviceNotFound,
+		},
+		{
+			err:              awserr.New("Throttling", "Rate exceeded", nil),
+			expectedType:     &ThrottledError{},
+			expectedExitCode: ExitCodeThrottled,
+		},
+		{
+			err:              awserr.New("RequestError", "send request failed", errors.New("dial tcp: lookup sts.amazonaws.com: no such host")),
+			expectedType:     &NetworkError{},
+			expectedExitCode: ExitCodeNetwork,
+		},
+	}
+
+	for _, testCase := range testCases {
+		output := classifyAWSError(testCase.err)
+
+		if reflect.TypeOf(output) != reflect.TypeOf(testCase.expectedType) {
+			t.Errorf("expected %T but got %T -- error was '%v'", testCase.expectedType, output, testCase.err)
+			continue
+		}
+
+		if output.(Error).ExitCode() != testCase.expectedExitCode {
+			t.Errorf("expected exit code %d but got %d", testCase.expectedExitCode, output.(Error).ExitCode())
+		}
+	}
+}
+
+func TestClassifyAWSErrorLeavesOtherErrorsUnchanged(t *testing.T) {
+	testCases := []error{
+		errors.New("something else"),
+		awserr.New("AccessDenied", "User is not authorized to perform: sts:AssumeRole", nil),
+	}
+
+	for _, err := range testCases {
+		if output := classifyAWSError(err); output != err {
+			t.Errorf("expected error to be unchanged but got %v", output)
+		}
+	}
+}
+
+func TestComputeMFADeviceSerialNumberPropagatesErrors(t *testing.T) {
+	a := &Authenticator{
+		stsClient: &fakeSTSClient{
+			callerIdentityErr: awserr.New("InvalidClientTokenId", "The security token included in the request is invalid.", nil),
+		},
+	}
+
+	_, err := a.computeMFADeviceSerialNumber()
+
+	if _, ok := err.(*InvalidCredentialsError); false == ok {
+		t.Errorf("expected InvalidCredentialsError but got %v", err)
+	}
+}
@@ -1,29 +1,122 @@
 package authenticator
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
+	"io"
+	"strconv"
 	"strings"
 )
 
+const errCodeAccessDenied = "AccessDenied"
+
+var errMFADeviceDiscoveryNotPermitted = errors.New("not permitted to list MFA devices")
+
+// findMFADeviceSerialNumber returns the serial number of the MFA device set via UseMFADevice.
+// Otherwise, it discovers the caller's MFA device using IAM, or derives its serial number if the caller isn't permitted to list MFA devices.
+func (a *Authenticator) findMFADeviceSerialNumber() (string, error) {
+	if len(a.mfaDeviceSerialNumber) != 0 {
+		return a.mfaDeviceSerialNumber, nil
+	}
+
+	serialNumber, err := a.discoverMFADeviceSerialNumber()
+
+	if err == errMFADeviceDiscoveryNotPermitted {
+		return a.computeMFADeviceSerialNumber()
+	}
+
+	return serialNumber, err
+}
+
+func (a *Authenticator) discoverMFADeviceSerialNumber() (string, error) {
+	if a.iamClient == nil {
+		return "", errMFADeviceDiscoveryNotPermitted
+	}
+
+	// Without a user name, IAM lists the MFA devices of the user who owns the access key making the request.
+	var serialNumbers []string
+
+	err := a.iamClient.ListMFADevicesPages(&iam.ListMFADevicesInput{}, func(page *iam.ListMFADevicesOutput, lastPage bool) bool {
+		for _, device := range page.MFADevices {
+			serialNumbers = append(serialNumbers, aws.StringValue(device.SerialNumber))
+		}
+
+		return true
+	})
+
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == errCodeAccessDenied {
+			return "", errMFADeviceDiscoveryNotPermitted
+		}
+
+		return "", classifyAWSError(err)
+	}
+
+	switch len(serialNumbers) {
+	case 0:
+		return "", &MFADeviceNotFoundError{errors.New("no MFA devices are registered for your IAM user")}
+	case 1:
+		return serialNumbers[0], nil
+	default:
+		return selectMFADevice(serialNumbers, a.promptInput, a.promptOutput)
+	}
+}
+
+func selectMFADevice(serialNumbers []string, input io.Reader, output io.Writer) (string, error) {
+	_, _ = fmt.Fprintln(output, "Multiple MFA devices are registered for your IAM user:")
+
+	for i, serialNumber := range serialNumbers {
+		_, _ = fmt.Fprintf(output, "  %d) %s\n", i+1, serialNumber)
+	}
+
+	reader := bufio.NewReader(input)
+
+	for {
+		_, _ = fmt.Fprintf(output, "Select the MFA device that generated your token [1-%d]: ", len(serialNumbers))
+
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return "", errors.New("unable to read MFA device selection")
+		}
+
+		selection, parseErr := strconv.Atoi(strings.TrimSpace(line))
+		if parseErr == nil && selection >= 1 && selection <= len(serialNumbers) {
+			return serialNumbers[selection-1], nil
+		}
+
+		if err == io.EOF {
+			return "", errors.New("invalid MFA device selection")
+		}
+
+		_, _ = fmt.Fprintln(output, "Invalid selection")
+	}
+}
+
 func (a *Authenticator) computeMFADeviceSerialNumber() (string, error) {
 	callerIdentity, err := a.stsClient.GetCallerIdentity(&sts.GetCallerIdentityInput{})
 
 	if err != nil {
-		return "", nil
+		return "", classifyAWSError(err)
 	}
 
-	awsAccountNumber := *callerIdentity.Account
-	userName := getUserNameFromCallerIdentity(callerIdentity)
+	caller, err := parseCallerARN(aws.StringValue(callerIdentity.Arn))
+	if err != nil {
+		return "", err
+	}
 
-	return computeARNForVirtualMFADevice(awsAccountNumber, userName), nil
-}
+	deviceName, err := caller.virtualMFADeviceName()
+	if err != nil {
+		return "", err
+	}
 
-func getUserNameFromCallerIdentity(callerIdentity *sts.GetCallerIdentityOutput) string {
-	const separator = "/"
-	return strings.Split(*callerIdentity.Arn, separator)[1]
+	return computeARNForVirtualMFADevice(caller.Partition, caller.AccountID, deviceName), nil
 }
 
-func computeARNForVirtualMFADevice(awsAccountNumber, userName string) string {
-	return fmt.Sprintf("arn:aws:iam::%s:mfa/%s", awsAccountNumber, userName)
+func computeARNForVirtualMFADevice(partition, awsAccountNumber, deviceName string) string {
+	return fmt.Sprintf("arn:%s:iam::%s:mfa/%s", partition, awsAccountNumber, deviceName)
 }
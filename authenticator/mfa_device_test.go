@@ -1,35 +1,167 @@
 package authenticator
 
-import "testing"
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"strings"
+	"testing"
+)
+
+type fakeIAMClient struct {
+	iamiface.IAMAPI
+
+	serialNumbers []string
+	err           error
+}
+
+func (f *fakeIAMClient) ListMFADevicesPages(input *iam.ListMFADevicesInput, fn func(*iam.ListMFADevicesOutput, bool) bool) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	output := &iam.ListMFADevicesOutput{}
+
+	for _, serialNumber := range f.serialNumbers {
+		output.MFADevices = append(output.MFADevices, &iam.MFADevice{SerialNumber: aws.String(serialNumber)})
+	}
+
+	fn(output, true)
+
+	return nil
+}
+
+func TestFindMFADeviceSerialNumber(t *testing.T) {
+	stsClient := &fakeSTSClient{
+		callerIdentity: &sts.GetCallerIdentityOutput{
+			Account: aws.String("123123123123"),
+			Arn:     aws.String("arn:aws:iam::123123123123:user/tony.stark"),
+		},
+	}
+
+	testCases := []struct {
+		iamClient      *fakeIAMClient
+		promptInput    string
+		expectedOutput string
+		expectError    bool
+	}{
+		{
+			iamClient:      &fakeIAMClient{serialNumbers: []string{"GAHT12345678"}},
+			expectedOutput: "GAHT12345678",
+		},
+		{
+			iamClient:      &fakeIAMClient{serialNumbers: []string{"arn:aws:iam::123123123123:mfa/phone", "GAHT12345678"}},
+			promptInput:    "x\n2\n",
+			expectedOutput: "GAHT12345678",
+		},
+		{
+			iamClient:      &fakeIAMClient{err: awserr.New("AccessDenied", "not authorized", nil)},
+			expectedOutput: "arn:aws:iam::123123123123:mfa/tony.stark",
+		},
+		{
+			iamClient:   &fakeIAMClient{err: awserr.New("ServiceFailure", "failure", nil)},
+			expectError: true,
+		},
+		{
+			iamClient:   &fakeIAMClient{},
+			expectError: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		a := &Authenticator{
+			stsClient:    stsClient,
+			iamClient:    testCase.iamClient,
+			promptInput:  strings.NewReader(testCase.promptInput),
+			promptOutput: &bytes.Buffer{},
+		}
+
+		output, err := a.findMFADeviceSerialNumber()
+
+		if testCase.expectError {
+			if err == nil {
+				t.Errorf("test case %d: expected an error but got serial number '%s'", i, output)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("test case %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if output != testCase.expectedOutput {
+			t.Errorf("test case %d: expected '%s' but got '%s'", i, testCase.expectedOutput, output)
+		}
+	}
+}
 
 func TestComputeARNForVirtualMFADevice(t *testing.T) {
 	testCases := []struct {
+		inputPartition        string
 		inputAwsAccountNumber string
 		inputUserName         string
 		expectedOutput        string
 	}{
 		{
+			inputPartition:        "aws",
 			inputAwsAccountNumber: "123123123123",
 			inputUserName:         "tony.stark",
 			expectedOutput:        "arn:aws:iam::123123123123:mfa/tony.stark",
 		},
 		{
+			inputPartition:        "aws",
 			inputAwsAccountNumber: "123412341234",
 			inputUserName:         "tony.stark@starkindustries.com",
 			expectedOutput:        "arn:aws:iam::123412341234:mfa/tony.stark@starkindustries.com",
 		},
 		{
+			inputPartition:        "aws",
 			inputAwsAccountNumber: "123456123456",
 			inputUserName:         "x-ray",
 			expectedOutput:        "arn:aws:iam::123456123456:mfa/x-ray",
 		},
+		{
+			inputPartition:        "aws-us-gov",
+			inputAwsAccountNumber: "123456123456",
+			inputUserName:         "x-ray",
+			expectedOutput:        "arn:aws-us-gov:iam::123456123456:mfa/x-ray",
+		},
+		{
+			inputPartition:        "aws-cn",
+			inputAwsAccountNumber: "123456123456",
+			inputUserName:         "x-ray",
+			expectedOutput:        "arn:aws-cn:iam::123456123456:mfa/x-ray",
+		},
 	}
 
 	for _, testCase := range testCases {
-		output := computeARNForVirtualMFADevice(testCase.inputAwsAccountNumber, testCase.inputUserName)
+		output := computeARNForVirtualMFADevice(testCase.inputPartition, testCase.inputAwsAccountNumber, testCase.inputUserName)
 
 		if output != testCase.expectedOutput {
 			t.Errorf("expected output was '%s' but actual output was '%s'", testCase.expectedOutput, output)
 		}
 	}
 }
+
+func TestFindMFADeviceSerialNumberWithExplicitDevice(t *testing.T) {
+	a := &Authenticator{
+		stsClient: &fakeSTSClient{},
+		iamClient: &fakeIAMClient{serialNumbers: []string{"arn:aws:iam::123123123123:mfa/phone"}},
+	}
+
+	a.UseMFADevice("GAHT12345678")
+
+	output, err := a.findMFADeviceSerialNumber()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if output != "GAHT12345678" {
+		t.Errorf("expected explicitly set serial number but got '%s'", output)
+	}
+}
@@ -0,0 +1,160 @@
+package authenticator
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/luhring/awsmfa/credentials"
+	"strings"
+	"time"
+)
+
+// STS limits the duration of sessions for roles assumed using credentials from another role.
+const MaximumChainedRoleSessionDuration = 1 * time.Hour
+
+const ErrFormatChainedRoleSessionDurationTooLong = "role chaining limits session duration to %s, but %s was requested (use a shorter duration for the role chain)"
+
+// STSClientFactory creates an STS client that signs its requests using the given credentials.
+type STSClientFactory func(c *credentials.Credentials) stsiface.STSAPI
+
+// RoleChain is an ordered list of roles to assume after MFA authentication.
+// The first role is assumed using the MFA session's credentials, and each subsequent role is assumed using the credentials of the role before it.
+type RoleChain struct {
+	Links           []RoleChainLink
+	SessionDuration time.Duration
+	NewSTSClient    STSClientFactory
+}
+
+// RoleChainLink is a role in a RoleChain, along with the profile to which the role's credentials are saved.
+type RoleChainLink struct {
+	ProfileName string
+	Role        *AssumeRoleStrategy
+}
+
+// SessionSources returns a description of how AuthenticateUsingMFA obtains the session credentials saved to each profile, keyed by profile name.
+// The description covers the strategy (including the role ARN), the profile holding the long-term credentials, and the roles assumed before each link of the role chain,
+// so that saved session credentials are only reused for the same request.
+func SessionSources(strategy Strategy, roleChain *RoleChain, selectedProfileName, targetProfileName string) map[string]string {
+	source := fmt.Sprintf("%s using '%s' profile", strategy.Description(), selectedProfileName)
+	sources := map[string]string{targetProfileName: source}
+
+	if roleChain != nil {
+		for _, link := range roleChain.Links {
+			source = fmt.Sprintf("%s, then %s", source, link.Role.Description())
+			sources[link.ProfileName] = source
+		}
+	}
+
+	return sources
+}
+
+// ParseRoleChainLinks parses a comma-separated list of 'profile-name=role-arn' pairs.
+func ParseRoleChainLinks(value string) ([]RoleChainLink, error) {
+	var links []RoleChainLink
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+
+		if len(item) == 0 {
+			continue
+		}
+
+		separatorIndex := strings.Index(item, "=")
+		if separatorIndex < 0 {
+			return nil, fmt.Errorf("role chain entry '%s' should have the format 'profile-name=role-arn'", item)
+		}
+
+		profileName := strings.TrimSpace(item[:separatorIndex])
+		roleARN := strings.TrimSpace(item[separatorIndex+1:])
+
+		if len(profileName) == 0 || len(roleARN) == 0 {
+			return nil, fmt.Errorf("role chain entry '%s' should have the format 'profile-name=role-arn'", item)
+		}
+
+		links = append(links, RoleChainLink{
+			ProfileName: profileName,
+			Role: &AssumeRoleStrategy{
+				RoleARN: roleARN,
+			},
+		})
+	}
+
+	return links, nil
+}
+
+// ValidateRoleChain checks the role chain before any requests are made to STS.
+// profileNamesInUse are the names of profiles that must not be overwritten by the role chain.
+func ValidateRoleChain(roleChain *RoleChain, strategy Strategy, profileNamesInUse ...string) error {
+	if roleChain == nil || len(roleChain.Links) == 0 {
+		return nil
+	}
+
+	if roleChain.NewSTSClient == nil {
+		return errors.New("role chain requires an STS client factory")
+	}
+
+	if roleChain.SessionDuration < MinimumSessionDuration {
+		return errors.New(ErrSessionDurationTooShort)
+	}
+
+	// Assuming a role using the MFA session's credentials isn't role chaining, but assuming any role using another role's credentials is.
+
+	_, isAssumingRole := strategy.(*AssumeRoleStrategy)
+	isChaining := isAssumingRole || len(roleChain.Links) > 1
+
+	maximumDuration := MaximumRoleSessionDuration
+	if isChaining {
+		maximumDuration = MaximumChainedRoleSessionDuration
+	}
+
+	if roleChain.SessionDuration > maximumDuration {
+		if isChaining {
+			return fmt.Errorf(ErrFormatChainedRoleSessionDurationTooLong, formatDuration(maximumDuration), formatDuration(roleChain.SessionDuration))
+		}
+
+		return fmt.Errorf(ErrFormatSessionDurationTooLong, formatDuration(maximumDuration), roleChain.Links[0].Role.Description())
+	}
+
+	seenProfileNames := make(map[string]bool)
+
+	for _, name := range profileNamesInUse {
+		seenProfileNames[name] = true
+	}
+
+	for _, link := range roleChain.Links {
+		if seenProfileNames[link.ProfileName] {
+			return fmt.Errorf("role chain can't save credentials to profile '%s' because that profile is already in use", link.ProfileName)
+		}
+
+		seenProfileNames[link.ProfileName] = true
+	}
+
+	return nil
+}
+
+// assumeRoleChain assumes each role in the chain in order, starting with the given credentials, and returns each link's credentials.
+func assumeRoleChain(roleChain *RoleChain, sourceCredentials *credentials.Credentials) ([]*credentials.Credentials, error) {
+	var result []*credentials.Credentials
+
+	currentCredentials := sourceCredentials
+	sessionDurationInSeconds := int64(roleChain.SessionDuration / time.Second)
+
+	for _, link := range roleChain.Links {
+		stsClient := roleChain.NewSTSClient(currentCredentials)
+
+		// The MFA context of the source session carries over, so no MFA token is needed (or allowed to be reused) here.
+		linkCredentials, err := link.Role.requestCredentials(stsClient, "", "", sessionDurationInSeconds)
+		if err != nil {
+			if classifiedErr := classifyAWSError(err); classifiedErr != err {
+				return nil, classifiedErr
+			}
+
+			return nil, fmt.Errorf("unable to assume role '%s' for profile '%s': %s", link.Role.RoleARN, link.ProfileName, err.Error())
+		}
+
+		result = append(result, linkCredentials)
+		currentCredentials = linkCredentials
+	}
+
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package authenticator
+
+import (
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/luhring/awsmfa/credentials"
+	"testing"
+	"time"
+)
+
+func TestParseRoleChainLinks(t *testing.T) {
+	links, err := ParseRoleChainLinks("member-a=arn:aws:iam::222222222222:role/Admin, member-b = arn:aws:iam::333333333333:role/ReadOnly")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links but got %d", len(links))
+	}
+
+	if links[0].ProfileName != "member-a" || links[0].Role.RoleARN != "arn:aws:iam::222222222222:role/Admin" {
+		t.Errorf("first link was parsed incorrectly: %v %v", links[0].ProfileName, links[0].Role.RoleARN)
+	}
+
+	if links[1].ProfileName != "member-b" || links[1].Role.RoleARN != "arn:aws:iam::333333333333:role/ReadOnly" {
+		t.Errorf("second link was parsed incorrectly: %v %v", links[1].ProfileName, links[1].Role.RoleARN)
+	}
+
+	for _, invalidValue := range []string{"arn:aws:iam::222222222222:role/Admin", "member-a=", "=arn:aws:iam::222222222222:role/Admin"} {
+		_, err := ParseRoleChainLinks(invalidValue)
+
+		if err == nil {
+			t.Errorf("expected an error for '%s'", invalidValue)
+		}
+	}
+}
+
+func TestSessionSources(t *testing.T) {
+	links, _ := ParseRoleChainLinks("member-a=arn:aws:iam::222222222222:role/Admin,member-b=arn:aws:iam::333333333333:role/Admin")
+	roleChain := &RoleChain{Links: links}
+	assumeRole := &AssumeRoleStrategy{RoleARN: "arn:aws:iam::111111111111:role/Admin"}
+
+	sessionSources := SessionSources(&SessionTokenStrategy{}, nil, "default", "default")
+	roleSources := SessionSources(assumeRole, nil, "default", "default")
+	otherProfileSources := SessionSources(&SessionTokenStrategy{}, nil, "work", "default")
+
+	if sessionSources["default"] == roleSources["default"] {
+		t.Error("expected session credentials and role credentials to have different sources")
+	}
+
+	if sessionSources["default"] == otherProfileSources["default"] {
+		t.Error("expected session credentials obtained using different profiles to have different sources")
+	}
+
+	chainSources := SessionSources(assumeRole, roleChain, "default", "default")
+
+	if chainSources["default"] != roleSources["default"] {
+		t.Error("expected the role chain not to change the target profile's source")
+	}
+
+	expectedSource := "credentials for role 'arn:aws:iam::111111111111:role/Admin' using 'default' profile, then credentials for role 'arn:aws:iam::222222222222:role/Admin', then credentials for role 'arn:aws:iam::333333333333:role/Admin'"
+
+	if chainSources["member-b"] != expectedSource {
+		t.Errorf("expected source '%s' but got '%s'", expectedSource, chainSources["member-b"])
+	}
+}
+
+func TestValidateRoleChain(t *testing.T) {
+	newSTSClient := func(c *credentials.Credentials) stsiface.STSAPI { return &fakeSTSClient{} }
+	links, _ := ParseRoleChainLinks("member-a=arn:aws:iam::222222222222:role/Admin,member-b=arn:aws:iam::333333333333:role/Admin")
+
+	testCases := []struct {
+		roleChain   *RoleChain
+		strategy    Strategy
+		expectError bool
+	}{
+		{
+			roleChain:   nil,
+			strategy:    &SessionTokenStrategy{},
+			expectError: false,
+		},
+		{
+			roleChain:   &RoleChain{Links: links[:1], SessionDuration: 2 * time.Hour, NewSTSClient: newSTSClient},
+			strategy:    &SessionTokenStrategy{},
+			expectError: false,
+		},
+		{
+			roleChain:   &RoleChain{Links: links, SessionDuration: 2 * time.Hour, NewSTSClient: newSTSClient},
+			strategy:    &SessionTokenStrategy{},
+			expectError: true,
+		},
+		{
+			roleChain:   &RoleChain{Links: links[:1], SessionDuration: 2 * time.Hour, NewSTSClient: newSTSClient},
+			strategy:    &AssumeRoleStrategy{RoleARN: "arn:aws:iam::111111111111:role/Hub"},
+			expectError: true,
+		},
+		{
+			roleChain:   &RoleChain{Links: links, SessionDuration: 1 * time.Hour, NewSTSClient: newSTSClient},
+			strategy:    &SessionTokenStrategy{},
+			expectError: false,
+		},
+		{
+			roleChain:   &RoleChain{Links: links, SessionDuration: 1 * time.Hour},
+			strategy:    &SessionTokenStrategy{},
+			expectError: true,
+		},
+	}
+
+	for i, testCase := range testCases {
+		err := ValidateRoleChain(testCase.roleChain, testCase.strategy, "default")
+
+		if (err != nil) != testCase.expectError {
+			t.Errorf("test case %d: expected error: %v, but got: %v", i, testCase.expectError, err)
+		}
+	}
+
+	err := ValidateRoleChain(&RoleChain{Links: links, SessionDuration: time.Hour, NewSTSClient: newSTSClient}, &SessionTokenStrategy{}, "member-b")
+	if err == nil {
+		t.Error("expected an error when the role chain would overwrite a profile in use")
+	}
+}
+
+func TestAssumeRoleChain(t *testing.T) {
+	links, _ := ParseRoleChainLinks("member-a=arn:aws:iam::222222222222:role/Admin,member-b=arn:aws:iam::333333333333:role/Admin")
+
+	var clients []*fakeSTSClient
+	var sourceCredentials []*credentials.Credentials
+
+	roleChain := &RoleChain{
+		Links:           links,
+		SessionDuration: time.Hour,
+		NewSTSClient: func(c *credentials.Credentials) stsiface.STSAPI {
+			client := &fakeSTSClient{}
+			clients = append(clients, client)
+			sourceCredentials = append(sourceCredentials, c)
+			return client
+		},
+	}
+
+	mfaSessionCredentials := credentials.New("ASIAMFASESSION", "secret", "token")
+
+	result, err := assumeRoleChain(roleChain, mfaSessionCredentials)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 2 || len(clients) != 2 {
+		t.Fatalf("expected 2 roles to be assumed but got %d", len(result))
+	}
+
+	if sourceCredentials[0] != mfaSessionCredentials {
+		t.Error("first role should be assumed using the MFA session's credentials")
+	}
+
+	if sourceCredentials[1] != result[0] {
+		t.Error("second role should be assumed using the first role's credentials")
+	}
+
+	for i, client := range clients {
+		input := client.assumeRoleInput
+
+		if *input.RoleArn != links[i].Role.RoleARN {
+			t.Errorf("expected role '%s' but got '%s'", links[i].Role.RoleARN, *input.RoleArn)
+		}
+
+		if input.TokenCode != nil || input.SerialNumber != nil {
+			t.Error("chained roles should not be assumed with an MFA token")
+		}
+
+		if *input.DurationSeconds != 3600 {
+			t.Errorf("expected duration of 3600 seconds but got %d", *input.DurationSeconds)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package authenticator
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/luhring/awsmfa/credentials"
+	"strconv"
+	"time"
+)
+
+// Strategy is a method of obtaining temporary credentials from STS using an MFA token.
+type Strategy interface {
+	// Description is a human-readable summary of what the strategy obtains, used in messages to the user.
+	Description() string
+	DefaultSessionDuration() time.Duration
+	MaximumSessionDuration() time.Duration
+
+	requestCredentials(stsClient stsiface.STSAPI, mfaToken, serialNumber string, sessionDurationInSeconds int64) (*credentials.Credentials, error)
+}
+
+// SessionTokenStrategy obtains session credentials for the IAM user via sts:GetSessionToken.
+type SessionTokenStrategy struct{}
+
+func (s *SessionTokenStrategy) Description() string {
+	return "session credentials"
+}
+
+func (s *SessionTokenStrategy) DefaultSessionDuration() time.Duration {
+	return DefaultSessionDuration
+}
+
+func (s *SessionTokenStrategy) MaximumSessionDuration() time.Duration {
+	return MaximumSessionDuration
+}
+
+func (s *SessionTokenStrategy) requestCredentials(stsClient stsiface.STSAPI, mfaToken, serialNumber string, sessionDurationInSeconds int64) (*credentials.Credentials, error) {
+	input := &sts.GetSessionTokenInput{
+		DurationSeconds: aws.Int64(sessionDurationInSeconds),
+		SerialNumber:    aws.String(serialNumber),
+		TokenCode:       aws.String(mfaToken),
+	}
+
+	result, err := stsClient.GetSessionToken(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCredentialsFromSTS(result.Credentials), nil
+}
+
+// AssumeRoleStrategy obtains credentials for an IAM role via sts:AssumeRole.
+type AssumeRoleStrategy struct {
+	RoleARN         string
+	RoleSessionName string // optional, a name is generated if empty
+	ExternalID      string // optional
+}
+
+func (s *AssumeRoleStrategy) Description() string {
+	return fmt.Sprintf("credentials for role '%s'", s.RoleARN)
+}
+
+func (s *AssumeRoleStrategy) DefaultSessionDuration() time.Duration {
+	return DefaultRoleSessionDuration
+}
+
+func (s *AssumeRoleStrategy) MaximumSessionDuration() time.Duration {
+	return MaximumRoleSessionDuration
+}
+
+func (s *AssumeRoleStrategy) requestCredentials(stsClient stsiface.STSAPI, mfaToken, serialNumber string, sessionDurationInSeconds int64) (*credentials.Credentials, error) {
+	input := &sts.AssumeRoleInput{
+		DurationSeconds: aws.Int64(sessionDurationInSeconds),
+		RoleArn:         aws.String(s.RoleARN),
+		RoleSessionName: aws.String(s.roleSessionName()),
+	}
+
+	// Roles assumed using an existing MFA session (i.e. role chaining) don't receive an MFA token.
+	if len(mfaToken) != 0 {
+		input.SerialNumber = aws.String(serialNumber)
+		input.TokenCode = aws.String(mfaToken)
+	}
+
+	if len(s.ExternalID) != 0 {
+		input.ExternalId = aws.String(s.ExternalID)
+	}
+
+	result, err := stsClient.AssumeRole(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCredentialsFromSTS(result.Credentials), nil
+}
+
+func (s *AssumeRoleStrategy) roleSessionName() string {
+	if len(s.RoleSessionName) != 0 {
+		return s.RoleSessionName
+	}
+
+	return "awsmfa-" + strconv.FormatInt(time.Now().Unix(), 10)
+}
+
+func newCredentialsFromSTS(c *sts.Credentials) *credentials.Credentials {
+	return credentials.NewWithExpiration(
+		*c.AccessKeyId,
+		*c.SecretAccessKey,
+		*c.SessionToken,
+		aws.TimeValue(c.Expiration),
+	)
+}
@@ -2,12 +2,25 @@ package authenticator
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
 	ErrMFATokenNotANumber      = "MFA token should be a number"
 	ErrMFATokenIncorrectLength = "MFA token should be six digits long"
+	ErrSessionDurationTooShort = "session duration should be at least 15 minutes"
+
+	ErrFormatSessionDurationTooLong = "session duration should be no more than %s when obtaining %s"
+)
+
+// Limits imposed by STS on the duration of sessions
+const (
+	MinimumSessionDuration     = 15 * time.Minute
+	MaximumSessionDuration     = 36 * time.Hour // GetSessionToken
+	MaximumRoleSessionDuration = 12 * time.Hour // AssumeRole, further limited by the role's own maximum session duration
 )
 
 func ValidateMFATokenFormat(mfaToken string) error {
@@ -22,3 +35,30 @@ func ValidateMFATokenFormat(mfaToken string) error {
 
 	return nil
 }
+
+func ValidateSessionDuration(sessionDuration time.Duration, strategy Strategy) error {
+	if sessionDuration < MinimumSessionDuration {
+		return errors.New(ErrSessionDurationTooShort)
+	}
+
+	if sessionDuration > strategy.MaximumSessionDuration() {
+		return fmt.Errorf(ErrFormatSessionDurationTooLong, formatDuration(strategy.MaximumSessionDuration()), strategy.Description())
+	}
+
+	return nil
+}
+
+// formatDuration formats durations such as 12h0m0s more readably (e.g. 12h).
+func formatDuration(d time.Duration) string {
+	formatted := d.String()
+
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+
+	return formatted
+}
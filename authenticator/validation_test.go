@@ -3,6 +3,7 @@ package authenticator
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 func TestValidateMFATokenFormat(t *testing.T) {
@@ -50,3 +51,67 @@ func TestValidateMFATokenFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateSessionDuration(t *testing.T) {
+	sessionTokenStrategy := &SessionTokenStrategy{}
+	assumeRoleStrategy := &AssumeRoleStrategy{RoleARN: "arn:aws:iam::123123123123:role/Admin"}
+
+	testCases := []struct {
+		sessionDuration time.Duration
+		strategy        Strategy
+		expectedOutput  error
+	}{
+		{
+			sessionDuration: 6 * time.Hour,
+			strategy:        sessionTokenStrategy,
+			expectedOutput:  nil,
+		},
+		{
+			sessionDuration: 15 * time.Minute,
+			strategy:        sessionTokenStrategy,
+			expectedOutput:  nil,
+		},
+		{
+			sessionDuration: 36 * time.Hour,
+			strategy:        sessionTokenStrategy,
+			expectedOutput:  nil,
+		},
+		{
+			sessionDuration: 14*time.Minute + 59*time.Second,
+			strategy:        sessionTokenStrategy,
+			expectedOutput:  errors.New(ErrSessionDurationTooShort),
+		},
+		{
+			sessionDuration: 0,
+			strategy:        assumeRoleStrategy,
+			expectedOutput:  errors.New(ErrSessionDurationTooShort),
+		},
+		{
+			sessionDuration: 37 * time.Hour,
+			strategy:        sessionTokenStrategy,
+			expectedOutput:  errors.New("session duration should be no more than 36h when obtaining session credentials"),
+		},
+		{
+			sessionDuration: 12 * time.Hour,
+			strategy:        assumeRoleStrategy,
+			expectedOutput:  nil,
+		},
+		{
+			sessionDuration: 13 * time.Hour,
+			strategy:        assumeRoleStrategy,
+			expectedOutput:  errors.New("session duration should be no more than 12h when obtaining credentials for role 'arn:aws:iam::123123123123:role/Admin'"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		output := ValidateSessionDuration(testCase.sessionDuration, testCase.strategy)
+
+		if output == nil && testCase.expectedOutput == nil {
+			continue
+		}
+
+		if output == nil || testCase.expectedOutput == nil || output.Error() != testCase.expectedOutput.Error() {
+			t.Errorf("expected error '%v' but received error '%v' -- sessionDuration was '%s'", testCase.expectedOutput, output, testCase.sessionDuration)
+		}
+	}
+}
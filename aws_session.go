@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	awscredentials "github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/luhring/awsmfa/authenticator"
+	"github.com/luhring/awsmfa/credentials"
+)
+
+const defaultRegion = "us-east-1"
+
+var dnsSuffixesByPartition = map[string]string{
+	endpoints.AwsPartitionID:      "amazonaws.com",
+	endpoints.AwsCnPartitionID:    "amazonaws.com.cn",
+	endpoints.AwsUsGovPartitionID: "amazonaws.com",
+}
+
+// awsSettings determine where awsmfa sends its requests to AWS.
+type awsSettings struct {
+	region      string
+	stsEndpoint string
+}
+
+// regionalSTSEndpoint returns the URL of the STS endpoint in the given region, e.g. https://sts.eu-west-1.amazonaws.com.
+// Regions that are newer than this version of the AWS SDK are assumed to be in the 'aws' partition, as the SDK's own endpoint resolver does.
+func regionalSTSEndpoint(region string) (string, error) {
+	partitionID := endpoints.AwsPartitionID
+
+	partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region)
+	if ok {
+		partitionID = partition.ID()
+	}
+
+	dnsSuffix, ok := dnsSuffixesByPartition[partitionID]
+	if false == ok {
+		return "", fmt.Errorf("unrecognized partition '%s' for region '%s', specify the STS endpoint using --sts-endpoint", partitionID, region)
+	}
+
+	return fmt.Sprintf("https://sts.%s.%s", region, dnsSuffix), nil
+}
+
+// newAWSSession creates a session that uses the long-term credentials of the given profile.
+func newAWSSession(profileName string, settings *awsSettings) *session.Session {
+	return session.Must(session.NewSessionWithOptions(session.Options{
+		Profile: profileName,
+		Config: aws.Config{
+			Region: aws.String(settings.region),
+		},
+	}))
+}
+
+func newSTSClient(awsSession *session.Session, settings *awsSettings) *sts.STS {
+	return sts.New(awsSession, &aws.Config{
+		Endpoint: aws.String(settings.stsEndpoint),
+	})
+}
+
+func newIAMClient(awsSession *session.Session) *iam.IAM {
+	return iam.New(awsSession)
+}
+
+// newSTSClientFactory returns a function that creates STS clients that use the given credentials instead of a profile's.
+func newSTSClientFactory(settings *awsSettings) authenticator.STSClientFactory {
+	return func(c *credentials.Credentials) stsiface.STSAPI {
+		awsSession := session.Must(session.NewSession(&aws.Config{
+			Credentials: awscredentials.NewStaticCredentials(c.AccessKeyID, c.SecretAccessKey, c.SessionToken),
+			Region:      aws.String(settings.region),
+		}))
+
+		return newSTSClient(awsSession, settings)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestRegionalSTSEndpoint(t *testing.T) {
+	testCases := []struct {
+		region         string
+		expectedOutput string
+	}{
+		{"us-east-1", "https://sts.us-east-1.amazonaws.com"},
+		{"eu-west-1", "https://sts.eu-west-1.amazonaws.com"},
+		{"us-gov-west-1", "https://sts.us-gov-west-1.amazonaws.com"},
+		{"cn-north-1", "https://sts.cn-north-1.amazonaws.com.cn"},
+		{"me-south-1", "https://sts.me-south-1.amazonaws.com"},
+		{"af-south-1", "https://sts.af-south-1.amazonaws.com"},
+		{"il-central-1", "https://sts.il-central-1.amazonaws.com"},
+	}
+
+	for _, testCase := range testCases {
+		output, err := regionalSTSEndpoint(testCase.region)
+
+		if err != nil {
+			t.Errorf("unexpected error: %v -- region was '%s'", err, testCase.region)
+			continue
+		}
+
+		if output != testCase.expectedOutput {
+			t.Errorf("expected '%s' but got '%s'", testCase.expectedOutput, output)
+		}
+	}
+}
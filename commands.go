@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+)
+
+const (
+	commandLogin             = "login"
+	commandRestore           = "restore"
+	commandStatus            = "status"
+	commandExec              = "exec"
+	commandEnv               = "env"
+	commandCredentialProcess = "credential-process"
+	commandVersion           = "version"
+	commandCompletion        = "completion"
+)
+
+// command is one of awsmfa's commands, e.g. 'awsmfa status'.
+type command struct {
+	name        string
+	arguments   string // syntax of the command's positional arguments, shown in help text
+	description string
+
+	minimumArguments         int
+	maximumArguments         int
+	requiresCommandArguments bool // whether arguments must follow "--"
+
+	flagGroups []func(*flag.FlagSet, *options)
+	run        func(*options)
+}
+
+// commands returns all of awsmfa's commands, in the order they're shown in help text.
+func commands() []*command {
+	return []*command{
+		{
+			name:             commandLogin,
+			arguments:        "[mfa-token]",
+			description:      "Obtain temporary credentials using MFA and save them to the credentials file (the default command, e.g. 'awsmfa 123456')",
+			maximumArguments: 1,
+			flagGroups:       []func(*flag.FlagSet, *options){addProfileFlag, addTargetProfileFlag, addLayoutFlag, addCredentialFlags, addVerifyFlag, addAWSFlags},
+			run:              login,
+		},
+		{
+			name:        commandRestore,
+			description: "Restore original credentials back to AWS credentials file (also: -r, --restore)",
+			flagGroups:  []func(*flag.FlagSet, *options){addProfileFlag, addTargetProfileFlag, addLayoutFlag},
+			run:         restore,
+		},
+		{
+			name:        commandStatus,
+			description: "Show the state of the target profile's credentials",
+			flagGroups:  []func(*flag.FlagSet, *options){addProfileFlag, addTargetProfileFlag, addLayoutFlag, addStatusVerifyFlag, addJSONFlag, addAWSFlags},
+			run:         status,
+		},
+		{
+			name:                     commandExec,
+			arguments:                "[mfa-token] -- command [arguments...]",
+			description:              "Run a command with temporary credentials in its environment, e.g. 'awsmfa exec 123456 -- terraform apply'",
+			maximumArguments:         1,
+			requiresCommandArguments: true,
+			flagGroups:               []func(*flag.FlagSet, *options){addProfileFlag, addLayoutFlag, addCredentialFlags, addAWSFlags},
+			run:                      execCommand,
+		},
+		{
+			name:             commandEnv,
+			arguments:        "[mfa-token]",
+			description:      "Print statements that set temporary credentials as environment variables, e.g. 'eval \"$(awsmfa env 123456)\"'",
+			maximumArguments: 1,
+			flagGroups:       []func(*flag.FlagSet, *options){addProfileFlag, addShellFlags, addLayoutFlag, addCredentialFlags, addAWSFlags},
+			run:              printEnv,
+		},
+		{
+			name:             commandCredentialProcess,
+			arguments:        "[mfa-token]",
+			description:      "Print temporary credentials as JSON, for use as a 'credential_process' in the AWS config file",
+			maximumArguments: 1,
+			flagGroups:       []func(*flag.FlagSet, *options){addProfileFlag, addLayoutFlag, addCredentialFlags, addAWSFlags},
+			run:              credentialProcess,
+		},
+		{
+			name:        commandVersion,
+			description: "Show the version of awsmfa and details of the build (also: --version)",
+			flagGroups:  []func(*flag.FlagSet, *options){addJSONFlag},
+			run:         showVersion,
+		},
+		{
+			name:             commandCompletion,
+			arguments:        "bash|zsh|fish",
+			description:      "Print a script that enables command-line completion in your shell",
+			minimumArguments: 1,
+			maximumArguments: 1,
+			run:              completion,
+		},
+	}
+}
+
+func findCommand(name string) *command {
+	return findCommandIn(commands(), name)
+}
+
+func findCommandIn(cmds []*command, name string) *command {
+	for _, cmd := range cmds {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
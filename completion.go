@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeCompletionScript writes a script that enables completion of awsmfa's commands and flags in the shell.
+func writeCompletionScript(w io.Writer, shell string, cmds []*command) error {
+	switch shell {
+	case shellBash:
+		return writeBashCompletionScript(w, cmds)
+	case shellZsh:
+		// zsh can use bash completion scripts via bashcompinit.
+		_, err := fmt.Fprintln(w, "autoload -U +X bashcompinit && bashcompinit")
+		if err != nil {
+			return err
+		}
+
+		return writeBashCompletionScript(w, cmds)
+	case shellFish:
+		return writeFishCompletionScript(w, cmds)
+	default:
+		return fmt.Errorf("unsupported shell '%s', must be one of: %s, %s, %s", shell, shellBash, shellZsh, shellFish)
+	}
+}
+
+func writeBashCompletionScript(w io.Writer, cmds []*command) error {
+	var b bytes.Buffer
+	var names []string
+
+	for _, cmd := range cmds {
+		names = append(names, cmd.name)
+	}
+
+	// Flags of the login command are offered alongside the command names, since login is the default command.
+	loginFlags := flagNames(findCommandIn(cmds, commandLogin))
+
+	fmt.Fprintln(&b, "_awsmfa() {")
+	fmt.Fprintln(&b, `    local cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(&b, `    if [ "$COMP_CWORD" -eq 1 ]; then`)
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(append(names, loginFlags...), " "))
+	fmt.Fprintln(&b, "        return")
+	fmt.Fprintln(&b, "    fi")
+	fmt.Fprintln(&b, `    case "${COMP_WORDS[1]}" in`)
+
+	for _, cmd := range cmds {
+		fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", cmd.name, strings.Join(flagNames(cmd), " "))
+	}
+
+	fmt.Fprintf(&b, "        *) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", strings.Join(loginFlags, " "))
+	fmt.Fprintln(&b, "    esac")
+	fmt.Fprintln(&b, "}")
+	fmt.Fprintln(&b, "complete -o default -F _awsmfa awsmfa")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func writeFishCompletionScript(w io.Writer, cmds []*command) error {
+	var b bytes.Buffer
+
+	fmt.Fprintln(&b, "complete -c awsmfa -f")
+
+	for _, cmd := range cmds {
+		fmt.Fprintf(&b, "complete -c awsmfa -n __fish_use_subcommand -a %s -d %s\n", cmd.name, quoteForFish(cmd.description))
+	}
+
+	for _, cmd := range cmds {
+		newFlagSet(cmd, &options{}).VisitAll(func(f *flag.Flag) {
+			if len(f.Name) == 1 {
+				return
+			}
+
+			fmt.Fprintf(&b, "complete -c awsmfa -n '__fish_seen_subcommand_from %s' -l %s -d %s\n", cmd.name, f.Name, quoteForFish(f.Usage))
+		})
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// flagNames returns the command's flags as they're typed, e.g. "--profile".
+func flagNames(cmd *command) []string {
+	var names []string
+
+	newFlagSet(cmd, &options{}).VisitAll(func(f *flag.Flag) {
+		if len(f.Name) > 1 {
+			names = append(names, "--"+f.Name)
+		}
+	})
+
+	return names
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletionScript(t *testing.T) {
+	cases := []struct {
+		shell         string
+		expectedLines []string
+	}{
+		{
+			shell: shellBash,
+			expectedLines: []string{
+				`        status) COMPREPLY=($(compgen -W "--help --json --layout --profile --region --sts-endpoint --target-profile --verify" -- "$cur")) ;;`,
+				"complete -o default -F _awsmfa awsmfa",
+			},
+		},
+		{
+			shell: shellZsh,
+			expectedLines: []string{
+				"autoload -U +X bashcompinit && bashcompinit",
+				"complete -o default -F _awsmfa awsmfa",
+			},
+		},
+		{
+			shell: shellFish,
+			expectedLines: []string{
+				"complete -c awsmfa -n __fish_use_subcommand -a version -d 'Show the version of awsmfa and details of the build (also: --version)'",
+				"complete -c awsmfa -n '__fish_seen_subcommand_from env' -l unset -d 'Print statements that remove the credential environment variables instead'",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := writeCompletionScript(&buf, tc.shell, commands())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(buf.String(), "\n")
+
+			for _, expectedLine := range tc.expectedLines {
+				if false == containsString(lines, expectedLine) {
+					t.Errorf("expected script to contain line %q", expectedLine)
+				}
+			}
+		})
+	}
+
+	err := writeCompletionScript(&bytes.Buffer{}, shellPowerShell, commands())
+	if err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
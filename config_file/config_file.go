@@ -0,0 +1,65 @@
+package config_file
+
+import (
+	"github.com/go-ini/ini"
+	"io/ioutil"
+)
+
+const defaultProfileName = "default"
+
+// ConfigFile represents the AWS 'config' file, where profile settings (other than credentials) are stored.
+type ConfigFile struct {
+	Filename      string
+	Configuration *ini.File
+}
+
+func NewFromDisk(filename string) (*ConfigFile, error) {
+	configFileContent, err := ioutil.ReadFile(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	configuration, err := ini.Load(configFileContent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigFile{
+		Filename:      filename,
+		Configuration: configuration,
+	}, nil
+}
+
+func NewEmpty() *ConfigFile {
+	return &ConfigFile{
+		Configuration: ini.Empty(),
+	}
+}
+
+// GetProfileValue returns the value of the named key in the profile's section, or an empty string if it isn't set.
+func (f *ConfigFile) GetProfileValue(profileName, keyName string) string {
+	for _, sectionName := range sectionNamesForProfile(profileName) {
+		section, err := f.Configuration.GetSection(sectionName)
+		if err != nil {
+			continue
+		}
+
+		if section.HasKey(keyName) {
+			return section.Key(keyName).String()
+		}
+	}
+
+	return ""
+}
+
+// sectionNamesForProfile returns the section names under which a profile's settings can be stored.
+// In the config file, non-default profiles are prefixed with "profile ".
+func sectionNamesForProfile(profileName string) []string {
+	if profileName == defaultProfileName {
+		return []string{defaultProfileName, "profile " + defaultProfileName}
+	}
+
+	return []string{"profile " + profileName}
+}
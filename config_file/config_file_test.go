@@ -0,0 +1,39 @@
+package config_file
+
+import (
+	"github.com/go-ini/ini"
+	"testing"
+)
+
+func TestGetProfileValue(t *testing.T) {
+	configuration, err := ini.Load([]byte(`[default]
+region = us-east-1
+
+[profile work]
+awsmfa_duration = 12h
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &ConfigFile{Configuration: configuration}
+
+	testCases := []struct {
+		profileName    string
+		keyName        string
+		expectedOutput string
+	}{
+		{"default", "region", "us-east-1"},
+		{"work", "awsmfa_duration", "12h"},
+		{"work", "region", ""},
+		{"missing", "region", ""},
+	}
+
+	for _, testCase := range testCases {
+		output := f.GetProfileValue(testCase.profileName, testCase.keyName)
+
+		if output != testCase.expectedOutput {
+			t.Errorf("expected '%s' but got '%s' -- profile was '%s', key was '%s'", testCase.expectedOutput, output, testCase.profileName, testCase.keyName)
+		}
+	}
+}
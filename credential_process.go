@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/luhring/awsmfa/authenticator"
+	"github.com/luhring/awsmfa/credentials"
+	"github.com/luhring/awsmfa/credentials_cache"
+	"github.com/luhring/awsmfa/environment"
+	"io"
+	"time"
+)
+
+// Version of the JSON document that AWS tools expect from a credential_process
+const credentialProcessVersion = 1
+
+// credentialProcessOutput is the JSON document that a credential_process writes to stdout.
+// See https://docs.aws.amazon.com/cli/latest/topic/config-vars.html#sourcing-credentials-from-external-processes
+type credentialProcessOutput struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+// getCachedOrNewCredentials returns cached temporary credentials for the profile if they remain valid,
+// and otherwise obtains new temporary credentials, generating an MFA token from the TOTP seed or prompting for one on the terminal if one wasn't given.
+// STS is called using the long-term credentials of sourceProfileName, which differs from profileName in the long-term-profile layout.
+// The credentials file is never modified.
+func getCachedOrNewCredentials(env *environment.Environment, request *loginRequest, profileName, sourceProfileName string) (*credentials.Credentials, error) {
+	err := authenticator.ValidateSessionDuration(request.sessionDuration, request.strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := credentials_cache.New(env.PathToCacheDirectory())
+	source := request.strategy.Description()
+
+	if false == request.shouldForce {
+		cachedCredentials, err := cache.Load(profileName, source)
+
+		if err == nil && cachedCredentials.RemainingLifetime(time.Now()) > request.minimumRemaining {
+			return cachedCredentials, nil
+		}
+	}
+
+	mfaToken, tty, err := obtainMFAToken(env, request, profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if tty != nil {
+		defer tty.Close()
+	}
+
+	awsSession := newAWSSession(sourceProfileName, request.aws)
+
+	auth, err := authenticator.New(newSTSClient(awsSession, request.aws), newIAMClient(awsSession), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if tty != nil {
+		auth.UsePrompt(tty, tty)
+	}
+
+	if len(request.serialNumber) != 0 {
+		auth.UseMFADevice(request.serialNumber)
+	}
+
+	newCredentials, err := auth.RequestCredentials(request.strategy, mfaToken, request.sessionDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cache.Save(profileName, source, newCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCredentials, nil
+}
+
+func writeCredentialProcessOutput(w io.Writer, c *credentials.Credentials) error {
+	output := &credentialProcessOutput{
+		Version:         credentialProcessVersion,
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.SessionToken,
+	}
+
+	if c.HasExpiration() {
+		output.Expiration = c.Expiration.UTC().Format(time.RFC3339)
+	}
+
+	return json.NewEncoder(w).Encode(output)
+}
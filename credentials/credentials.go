@@ -2,22 +2,32 @@ package credentials
 
 import (
 	"strings"
+	"time"
 )
 
 type Credentials struct {
 	AccessKeyID     string
 	SecretAccessKey string
 	SessionToken    string
+	Expiration      time.Time // zero if unknown or if the credentials don't expire
+	Source          string    // describes how session credentials were obtained, e.g. the role ARN; empty if unknown
 }
 
 func New(accessKeyID, secretAccessKey, sessionToken string) *Credentials {
 	return &Credentials{
-		accessKeyID,
-		secretAccessKey,
-		sessionToken,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		SessionToken:    sessionToken,
 	}
 }
 
+func NewWithExpiration(accessKeyID, secretAccessKey, sessionToken string, expiration time.Time) *Credentials {
+	c := New(accessKeyID, secretAccessKey, sessionToken)
+	c.Expiration = expiration
+
+	return c
+}
+
 func (c *Credentials) ArePermanent() bool {
 	return c.hasPermanentAccessKeyID() && false == c.HasSessionToken()
 }
@@ -31,3 +41,17 @@ func (c *Credentials) hasPermanentAccessKeyID() bool {
 
 	return strings.HasPrefix(c.AccessKeyID, accessKeyIDPrefix)
 }
+
+func (c *Credentials) HasExpiration() bool {
+	return false == c.Expiration.IsZero()
+}
+
+// RemainingLifetime returns how long the credentials remain valid after the given time, or zero if they've expired.
+// Credentials without a known expiration have no remaining lifetime.
+func (c *Credentials) RemainingLifetime(now time.Time) time.Duration {
+	if false == c.HasExpiration() || false == c.Expiration.After(now) {
+		return 0
+	}
+
+	return c.Expiration.Sub(now)
+}
@@ -0,0 +1,82 @@
+package credentials_cache
+
+import (
+	"encoding/json"
+	"github.com/luhring/awsmfa/credentials"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Cache stores temporary credentials on disk, so that they can be served without re-authenticating until they expire.
+type Cache struct {
+	Directory string
+}
+
+type cacheEntry struct {
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	SessionToken    string    `json:"session_token"`
+	Expiration      time.Time `json:"expiration"`
+	Source          string    `json:"source"` // describes how the credentials were obtained, e.g. the role ARN
+}
+
+var unsafeFilenameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func New(directory string) *Cache {
+	return &Cache{
+		Directory: directory,
+	}
+}
+
+// Load returns the cached credentials for the key, provided they were obtained from the same source.
+func (c *Cache) Load(key, source string) (*credentials.Credentials, error) {
+	content, err := ioutil.ReadFile(c.pathForKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cacheEntry
+
+	err = json.Unmarshal(content, &entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Source != source {
+		return nil, os.ErrNotExist
+	}
+
+	return credentials.NewWithExpiration(
+		entry.AccessKeyID,
+		entry.SecretAccessKey,
+		entry.SessionToken,
+		entry.Expiration,
+	), nil
+}
+
+func (c *Cache) Save(key, source string, creds *credentials.Credentials) error {
+	err := os.MkdirAll(c.Directory, 0700)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(&cacheEntry{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expiration:      creds.Expiration,
+		Source:          source,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.pathForKey(key), content, 0600)
+}
+
+func (c *Cache) pathForKey(key string) string {
+	return filepath.Join(c.Directory, unsafeFilenameCharacters.ReplaceAllString(key, "_")+".json")
+}
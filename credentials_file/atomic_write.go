@@ -0,0 +1,126 @@
+package credentials_file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Limit on the number of symbolic links followed when resolving the file to write, as a guard against loops
+const maximumSymlinkDepth = 40
+
+// WriteFileAtomically replaces the file's content such that the file is never left partially written:
+// the content is written to a temporary file in the same directory, synced to disk, and renamed over the file.
+// New files are created with mode 0600. Existing files keep their mode and ownership.
+// If filename is a symbolic link, the link's target is replaced, and the link itself is left intact.
+func WriteFileAtomically(filename string, content []byte) error {
+	target, err := resolveSymlinks(filename)
+	if err != nil {
+		return err
+	}
+
+	existingFileInfo, err := os.Stat(target)
+	if err != nil && false == os.IsNotExist(err) {
+		return err
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(target), "."+filepath.Base(target)+".tmp")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file to write %s: %s", target, err.Error())
+	}
+
+	tempFilename := tempFile.Name()
+	didRename := false
+
+	defer func() {
+		if false == didRename {
+			_ = os.Remove(tempFilename)
+		}
+	}()
+
+	_, err = tempFile.Write(content)
+	if err == nil {
+		err = tempFile.Sync()
+	}
+
+	closeErr := tempFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %s", target, err.Error())
+	}
+
+	mode := os.FileMode(0600)
+	if existingFileInfo != nil {
+		mode = existingFileInfo.Mode().Perm()
+
+		err = preserveOwnership(tempFilename, existingFileInfo)
+		if err != nil {
+			return fmt.Errorf("unable to preserve ownership of %s: %s", target, err.Error())
+		}
+	}
+
+	err = os.Chmod(tempFilename, mode)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tempFilename, target)
+	if err != nil {
+		return err
+	}
+
+	didRename = true
+	syncDirectory(filepath.Dir(target))
+
+	return nil
+}
+
+// resolveSymlinks returns the path of the file that filename refers to, following symbolic links.
+// Unlike filepath.EvalSymlinks, it succeeds when the final target doesn't exist yet.
+func resolveSymlinks(filename string) (string, error) {
+	path := filename
+
+	for i := 0; i < maximumSymlinkDepth; i++ {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+
+		linkTarget, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+
+		if false == filepath.IsAbs(linkTarget) {
+			linkTarget = filepath.Join(filepath.Dir(path), linkTarget)
+		}
+
+		path = linkTarget
+	}
+
+	return "", fmt.Errorf("too many levels of symbolic links: %s", filename)
+}
+
+// syncDirectory makes the rename durable on file systems that require it. Not all platforms support syncing directories,
+// and the file's content is already safely on disk, so errors are ignored.
+func syncDirectory(directory string) {
+	d, err := os.Open(directory)
+	if err != nil {
+		return
+	}
+
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,127 @@
+package credentials_file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomically(t *testing.T) {
+	dir, err := ioutil.TempDir("", "awsmfa-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("creates new file readable only by owner", func(t *testing.T) {
+		filename := filepath.Join(dir, "new")
+
+		err := WriteFileAtomically(filename, []byte("[default]\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertFileContent(t, filename, "[default]\n")
+		assertFileMode(t, filename, 0600)
+	})
+
+	t.Run("preserves mode of existing file", func(t *testing.T) {
+		filename := filepath.Join(dir, "existing")
+
+		err := ioutil.WriteFile(filename, []byte("old"), 0640)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.Chmod(filename, 0640) // not subject to umask
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = WriteFileAtomically(filename, []byte("new"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertFileContent(t, filename, "new")
+		assertFileMode(t, filename, 0640)
+	})
+
+	t.Run("writes through symbolic link", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("creating symbolic links requires elevated privileges on Windows")
+		}
+
+		target := filepath.Join(dir, "target")
+		link := filepath.Join(dir, "link")
+
+		err := ioutil.WriteFile(target, []byte("old"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.Symlink("target", link)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = WriteFileAtomically(link, []byte("new"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := os.Lstat(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			t.Error("expected symbolic link to be left intact")
+		}
+
+		assertFileContent(t, target, "new")
+	})
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != "" || entry.Name()[0] == '.' {
+			t.Errorf("expected no temporary files to be left behind, found %s", entry.Name())
+		}
+	}
+}
+
+func assertFileContent(t *testing.T, filename, expectedContent string) {
+	t.Helper()
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != expectedContent {
+		t.Errorf("expected content %q but got %q", expectedContent, content)
+	}
+}
+
+func assertFileMode(t *testing.T, filename string, expectedMode os.FileMode) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != expectedMode {
+		t.Errorf("expected mode %o but got %o", expectedMode, info.Mode().Perm())
+	}
+}
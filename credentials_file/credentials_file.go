@@ -1,22 +1,34 @@
 package credentials_file
 
 import (
-	"errors"
+	"bytes"
 	"github.com/go-ini/ini"
-	"github.com/luhring/awsmfa/credentials"
 	"io/ioutil"
-	"os"
+	"time"
 )
 
 const (
 	keyNameForAccessKeyID     = "aws_access_key_id"
 	keyNameForSecretAccessKey = "aws_secret_access_key"
 	keyNameForSessionToken    = "aws_session_token"
+
+	// AWS SDKs ignore this key, so it's safe to record the expiration of session credentials alongside them.
+	keyNameForExpiration = "x_security_token_expires"
+
+	// Also ignored by AWS SDKs, this key records how session credentials were obtained, so that they're only reused for the same request.
+	keyNameForSource = "x_awsmfa_source"
 )
 
+// Format of the expiration time stored in credentials files
+const expirationFormat = time.RFC3339
+
 type CredentialsFile struct {
 	Filename      string
 	Configuration *ini.File
+
+	// rawContent holds the file's exact bytes when it was loaded from disk or merged into, so that saving doesn't reformat it.
+	// Configuration is treated as a read-only view of rawContent in that case.
+	rawContent []byte
 }
 
 func NewFromDisk(filename string) (*CredentialsFile, error) {
@@ -26,7 +38,12 @@ func NewFromDisk(filename string) (*CredentialsFile, error) {
 		return nil, err
 	}
 
-	configuration, err := ini.Load(credentialsFileContent)
+	return NewFromContent(credentialsFileContent, filename)
+}
+
+// NewFromContent parses content that was read from filename.
+func NewFromContent(content []byte, filename string) (*CredentialsFile, error) {
+	configuration, err := ini.Load(content)
 
 	if err != nil {
 		return nil, err
@@ -35,56 +52,27 @@ func NewFromDisk(filename string) (*CredentialsFile, error) {
 	return &CredentialsFile{
 		Filename:      filename,
 		Configuration: configuration,
+		rawContent:    content,
 	}, nil
 }
 
-func NewFromCredentials(c *credentials.Credentials, profileName, filename string) (*CredentialsFile, error) {
-	configuration := ini.Empty()
-
-	profile, err := configuration.NewSection(profileName)
-	if err != nil {
-		return nil, err
+// Bytes returns the file's content, as it would be saved.
+// For files that were loaded or merged into, that's their exact content, so changes made directly to Configuration aren't included.
+func (f *CredentialsFile) Bytes() ([]byte, error) {
+	if f.rawContent != nil {
+		return f.rawContent, nil
 	}
 
-	err = createNewKeyInProfile(profile, keyNameForAccessKeyID, c.AccessKeyID)
-	if err != nil {
-		return nil, err
-	}
+	var buf bytes.Buffer
 
-	err = createNewKeyInProfile(profile, keyNameForSecretAccessKey, c.SecretAccessKey)
+	_, err := f.Configuration.WriteTo(&buf)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.HasSessionToken() {
-		err = createNewKeyInProfile(profile, keyNameForSessionToken, c.SessionToken)
-
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return &CredentialsFile{
-		Filename:      filename,
-		Configuration: configuration,
-	}, nil
-}
-
-func NewFromConfiguration(configuration *ini.File, filename string) (*CredentialsFile, error) {
-	if len(filename) == 0 {
-		return nil, errors.New("filename parameter cannot be an empty string")
-	}
-
-	return &CredentialsFile{
-		Filename:      filename,
-		Configuration: configuration,
-	}, nil
-}
-
-func (f *CredentialsFile) Save() error {
-	return f.Configuration.SaveTo(f.Filename)
+	return buf.Bytes(), nil
 }
 
-func (f *CredentialsFile) Delete() error {
-	return os.Remove(f.Filename)
+func formatExpiration(expiration time.Time) string {
+	return expiration.UTC().Format(expirationFormat)
 }
@@ -0,0 +1,331 @@
+package credentials_file
+
+import (
+	"bytes"
+	"github.com/go-ini/ini"
+	"github.com/luhring/awsmfa/credentials"
+	"strings"
+)
+
+type keyValuePair struct {
+	key   string
+	value string
+}
+
+// NewEmpty returns a credentials file without any profiles, which hasn't been saved yet.
+func NewEmpty(filename string) *CredentialsFile {
+	return &CredentialsFile{
+		Filename:      filename,
+		Configuration: ini.Empty(),
+		rawContent:    []byte{},
+	}
+}
+
+// MergeCredentialsIntoProfile replaces (or adds) only the credential keys of the named profile.
+// Every other line of the file, including comments and unrelated profiles, is left byte-for-byte intact.
+func (f *CredentialsFile) MergeCredentialsIntoProfile(c *credentials.Credentials, profileName string) error {
+	err := f.ensureRawContent()
+	if err != nil {
+		return err
+	}
+
+	keysToSet := []keyValuePair{
+		{keyNameForAccessKeyID, c.AccessKeyID},
+		{keyNameForSecretAccessKey, c.SecretAccessKey},
+	}
+	var keysToRemove []string
+
+	if c.HasSessionToken() {
+		keysToSet = append(keysToSet, keyValuePair{keyNameForSessionToken, c.SessionToken})
+	} else {
+		keysToRemove = append(keysToRemove, keyNameForSessionToken)
+	}
+
+	if c.HasExpiration() {
+		keysToSet = append(keysToSet, keyValuePair{keyNameForExpiration, formatExpiration(c.Expiration)})
+	} else {
+		keysToRemove = append(keysToRemove, keyNameForExpiration)
+	}
+
+	if len(c.Source) != 0 {
+		keysToSet = append(keysToSet, keyValuePair{keyNameForSource, c.Source})
+	} else {
+		keysToRemove = append(keysToRemove, keyNameForSource)
+	}
+
+	mergedContent := mergeKeysIntoSection(f.rawContent, profileName, keysToSet, keysToRemove)
+
+	configuration, err := ini.Load(mergedContent)
+	if err != nil {
+		return err
+	}
+
+	f.rawContent = mergedContent
+	f.Configuration = configuration
+
+	return nil
+}
+
+// RemoveProfile deletes the named profile, leaving every other line of the file intact. It does nothing if the profile doesn't exist.
+func (f *CredentialsFile) RemoveProfile(profileName string) error {
+	err := f.ensureRawContent()
+	if err != nil {
+		return err
+	}
+
+	lines := splitLinesKeepingEndings(string(f.rawContent))
+
+	sectionStart, sectionEnd := findSection(lines, profileName)
+	if sectionStart < 0 {
+		return nil
+	}
+
+	// A profile's range includes the blank lines that follow it, except for the last profile, whose separating blank line precedes it.
+	if sectionEnd == len(lines) && sectionStart > 0 && strings.TrimSpace(lines[sectionStart-1]) == "" {
+		sectionStart--
+	}
+
+	remainingContent := []byte(strings.Join(append(lines[:sectionStart:sectionStart], lines[sectionEnd:]...), ""))
+
+	configuration, err := ini.Load(remainingContent)
+	if err != nil {
+		return err
+	}
+
+	f.rawContent = remainingContent
+	f.Configuration = configuration
+
+	return nil
+}
+
+// ProfileNames returns the names of the file's profiles, in the order they appear.
+func (f *CredentialsFile) ProfileNames() []string {
+	var names []string
+
+	for _, section := range f.Configuration.Sections() {
+		if section.Name() == ini.DEFAULT_SECTION && len(section.Keys()) == 0 {
+			continue
+		}
+
+		names = append(names, section.Name())
+	}
+
+	return names
+}
+
+func (f *CredentialsFile) ensureRawContent() error {
+	if f.rawContent != nil {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+
+	_, err := f.Configuration.WriteTo(&buffer)
+	if err != nil {
+		return err
+	}
+
+	f.rawContent = buffer.Bytes()
+
+	return nil
+}
+
+func mergeKeysIntoSection(content []byte, sectionName string, keysToSet []keyValuePair, keysToRemove []string) []byte {
+	lineEnding := "\n"
+	if bytes.Contains(content, []byte("\r\n")) {
+		lineEnding = "\r\n"
+	}
+
+	lines := splitLinesKeepingEndings(string(content))
+
+	sectionStart, sectionEnd := findSection(lines, sectionName)
+
+	if sectionStart < 0 {
+		return appendSection(content, sectionName, keysToSet, lineEnding)
+	}
+
+	written := make(map[string]bool)
+	var result []string
+
+	result = append(result, lines[:sectionStart+1]...)
+
+	// Missing keys are inserted after the section's last key, since comments that follow it may belong to the next section.
+	lastKeyLine := sectionStart
+
+	for i := sectionStart + 1; i < sectionEnd; i++ {
+		line := lines[i]
+		key, separatorEnd, isKeyLine := parseKeyLine(line)
+
+		if isKeyLine && containsString(keysToRemove, key) {
+			continue
+		}
+
+		if isKeyLine {
+			if value, ok := lookUpValue(keysToSet, key); ok {
+				line = line[:separatorEnd] + value + lineEndingOf(line)
+				written[key] = true
+			}
+		}
+
+		result = append(result, line)
+
+		if isKeyLine {
+			lastKeyLine = len(result) - 1
+		}
+	}
+
+	var missingLines []string
+	for _, pair := range keysToSet {
+		if false == written[pair.key] {
+			missingLines = append(missingLines, pair.key+" = "+pair.value+lineEnding)
+		}
+	}
+
+	if len(missingLines) > 0 {
+		if false == strings.HasSuffix(result[lastKeyLine], "\n") {
+			result[lastKeyLine] += lineEnding
+		}
+
+		tail := append([]string{}, result[lastKeyLine+1:]...)
+		result = append(append(result[:lastKeyLine+1], missingLines...), tail...)
+	}
+
+	result = append(result, lines[sectionEnd:]...)
+
+	return []byte(strings.Join(result, ""))
+}
+
+func appendSection(content []byte, sectionName string, keysToSet []keyValuePair, lineEnding string) []byte {
+	var buffer bytes.Buffer
+	buffer.Write(content)
+
+	if len(content) > 0 {
+		if false == bytes.HasSuffix(content, []byte("\n")) {
+			buffer.WriteString(lineEnding)
+		}
+
+		buffer.WriteString(lineEnding)
+	}
+
+	buffer.WriteString("[" + sectionName + "]" + lineEnding)
+
+	for _, pair := range keysToSet {
+		buffer.WriteString(pair.key + " = " + pair.value + lineEnding)
+	}
+
+	return buffer.Bytes()
+}
+
+// findSection returns the index of the section's header line and the index of the line after its last line, or -1 if the section doesn't exist.
+func findSection(lines []string, sectionName string) (int, int) {
+	start := -1
+
+	for i, line := range lines {
+		name, isHeader := parseSectionHeader(line)
+		if false == isHeader {
+			continue
+		}
+
+		if start >= 0 {
+			return start, i
+		}
+
+		if name == sectionName {
+			start = i
+		}
+	}
+
+	if start < 0 {
+		return -1, -1
+	}
+
+	return start, len(lines)
+}
+
+// parseSectionHeader returns the name of a '[name]' line. Like go-ini, it ignores anything after the closing bracket, such as a comment.
+func parseSectionHeader(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+
+	if false == strings.HasPrefix(trimmed, "[") {
+		return "", false
+	}
+
+	closingIndex := strings.Index(trimmed, "]")
+	if closingIndex < 0 {
+		return "", false
+	}
+
+	return strings.TrimSpace(trimmed[1:closingIndex]), true
+}
+
+// parseKeyLine returns the key of a 'key = value' line and the offset at which its value begins.
+func parseKeyLine(line string) (string, int, bool) {
+	trimmed := strings.TrimSpace(line)
+
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+		return "", 0, false
+	}
+
+	separatorIndex := strings.IndexAny(line, "=:")
+	if separatorIndex < 0 {
+		return "", 0, false
+	}
+
+	key := strings.TrimSpace(line[:separatorIndex])
+
+	valueStart := separatorIndex + 1
+	for valueStart < len(line) && (line[valueStart] == ' ' || line[valueStart] == '\t') {
+		valueStart++
+	}
+
+	return key, valueStart, true
+}
+
+func splitLinesKeepingEndings(content string) []string {
+	var lines []string
+
+	for len(content) > 0 {
+		i := strings.Index(content, "\n")
+		if i < 0 {
+			lines = append(lines, content)
+			break
+		}
+
+		lines = append(lines, content[:i+1])
+		content = content[i+1:]
+	}
+
+	return lines
+}
+
+func lineEndingOf(line string) string {
+	if strings.HasSuffix(line, "\r\n") {
+		return "\r\n"
+	}
+
+	if strings.HasSuffix(line, "\n") {
+		return "\n"
+	}
+
+	return ""
+}
+
+func lookUpValue(pairs []keyValuePair, key string) (string, bool) {
+	for _, pair := range pairs {
+		if pair.key == key {
+			return pair.value, true
+		}
+	}
+
+	return "", false
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
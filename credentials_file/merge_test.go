@@ -0,0 +1,241 @@
+package credentials_file
+
+import (
+	"github.com/luhring/awsmfa/credentials"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeCredentialsIntoProfile(t *testing.T) {
+	sessionCredentials := credentials.New("ASIANEW", "new-secret", "new-token")
+
+	testCases := []struct {
+		name           string
+		content        string
+		profileName    string
+		c              *credentials.Credentials
+		expectedOutput string
+	}{
+		{
+			name:        "replaces keys in existing profile and leaves other profiles intact",
+			profileName: "default",
+			c:           sessionCredentials,
+			content: `# my credentials
+[default]
+aws_access_key_id=AKIAOLD
+aws_secret_access_key = old-secret
+region = us-east-1
+
+[prod]
+; production keys
+aws_access_key_id   =   AKIAPROD
+aws_secret_access_key=prod-secret
+`,
+			expectedOutput: `# my credentials
+[default]
+aws_access_key_id=ASIANEW
+aws_secret_access_key = new-secret
+region = us-east-1
+aws_session_token = new-token
+
+[prod]
+; production keys
+aws_access_key_id   =   AKIAPROD
+aws_secret_access_key=prod-secret
+`,
+		},
+		{
+			name:        "inserts new keys after the last key rather than before the next profile's comment",
+			profileName: "default",
+			c:           sessionCredentials,
+			content: `[default]
+aws_access_key_id = AKIAOLD
+aws_secret_access_key = old-secret
+
+# production account
+[prod]
+aws_access_key_id = AKIAPROD
+aws_secret_access_key = prod-secret
+`,
+			expectedOutput: `[default]
+aws_access_key_id = ASIANEW
+aws_secret_access_key = new-secret
+aws_session_token = new-token
+
+# production account
+[prod]
+aws_access_key_id = AKIAPROD
+aws_secret_access_key = prod-secret
+`,
+		},
+		{
+			name:           "recognizes a profile whose header is followed by a comment",
+			profileName:    "default",
+			c:              sessionCredentials,
+			content:        "[default] # work laptop\naws_access_key_id = AKIAOLD\naws_secret_access_key = old-secret\n",
+			expectedOutput: "[default] # work laptop\naws_access_key_id = ASIANEW\naws_secret_access_key = new-secret\naws_session_token = new-token\n",
+		},
+		{
+			name:        "appends missing profile",
+			profileName: "work-mfa",
+			c:           sessionCredentials,
+			content: `[work]
+aws_access_key_id = AKIAWORK
+aws_secret_access_key = work-secret`,
+			expectedOutput: `[work]
+aws_access_key_id = AKIAWORK
+aws_secret_access_key = work-secret
+
+[work-mfa]
+aws_access_key_id = ASIANEW
+aws_secret_access_key = new-secret
+aws_session_token = new-token
+`,
+		},
+		{
+			name:           "removes stale session token when writing permanent credentials",
+			profileName:    "default",
+			c:              credentials.New("AKIAPERM", "perm-secret", ""),
+			content:        "[default]\r\naws_access_key_id = ASIAOLD\r\naws_session_token = old-token\r\naws_secret_access_key = old-secret\r\n",
+			expectedOutput: "[default]\r\naws_access_key_id = AKIAPERM\r\naws_secret_access_key = perm-secret\r\n",
+		},
+		{
+			name:        "records expiration of session credentials",
+			profileName: "default",
+			c:           credentials.NewWithExpiration("ASIANEW", "new-secret", "new-token", time.Date(2019, 1, 1, 18, 0, 0, 0, time.UTC)),
+			content: `[default]
+aws_access_key_id = ASIAOLD
+aws_secret_access_key = old-secret
+aws_session_token = old-token
+x_security_token_expires = 2019-01-01T12:00:00Z
+`,
+			expectedOutput: `[default]
+aws_access_key_id = ASIANEW
+aws_secret_access_key = new-secret
+aws_session_token = new-token
+x_security_token_expires = 2019-01-01T18:00:00Z
+`,
+		},
+		{
+			name:           "removes expiration when writing permanent credentials",
+			profileName:    "default",
+			c:              credentials.New("AKIAPERM", "perm-secret", ""),
+			content:        "[default]\naws_access_key_id = ASIAOLD\naws_secret_access_key = old-secret\nx_security_token_expires = 2019-01-01T12:00:00Z\n",
+			expectedOutput: "[default]\naws_access_key_id = AKIAPERM\naws_secret_access_key = perm-secret\n",
+		},
+		{
+			name:           "creates profile in empty file",
+			profileName:    "default",
+			c:              sessionCredentials,
+			content:        "",
+			expectedOutput: "[default]\naws_access_key_id = ASIANEW\naws_secret_access_key = new-secret\naws_session_token = new-token\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		f := &CredentialsFile{
+			Filename:   "credentials",
+			rawContent: []byte(testCase.content),
+		}
+
+		err := f.MergeCredentialsIntoProfile(testCase.c, testCase.profileName)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", testCase.name, err)
+			continue
+		}
+
+		output := string(f.rawContent)
+
+		if output != testCase.expectedOutput {
+			t.Errorf("%s: expected:\n%q\nbut got:\n%q", testCase.name, testCase.expectedOutput, output)
+		}
+
+		c, err := f.GetCredentialsFromProfile(testCase.profileName)
+		if err != nil {
+			t.Errorf("%s: unable to read back merged credentials: %v", testCase.name, err)
+			continue
+		}
+
+		if *c != *testCase.c {
+			t.Errorf("%s: expected parsed credentials %v but got %v", testCase.name, testCase.c, c)
+		}
+	}
+}
+
+func TestRemoveProfile(t *testing.T) {
+	const content = `# my credentials
+[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[work]
+aws_access_key_id = AKIAWORK
+aws_secret_access_key = work-secret
+
+[personal]
+aws_access_key_id = AKIAPERSONAL
+aws_secret_access_key = personal-secret
+`
+
+	testCases := []struct {
+		profileName          string
+		expectedOutput       string
+		expectedProfileNames []string
+	}{
+		{
+			profileName: "work",
+			expectedOutput: `# my credentials
+[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[personal]
+aws_access_key_id = AKIAPERSONAL
+aws_secret_access_key = personal-secret
+`,
+			expectedProfileNames: []string{"default", "personal"},
+		},
+		{
+			profileName: "personal",
+			expectedOutput: `# my credentials
+[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[work]
+aws_access_key_id = AKIAWORK
+aws_secret_access_key = work-secret
+`,
+			expectedProfileNames: []string{"default", "work"},
+		},
+		{
+			profileName:          "missing",
+			expectedOutput:       content,
+			expectedProfileNames: []string{"default", "work", "personal"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		f, err := NewFromContent([]byte(content), "credentials")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = f.RemoveProfile(testCase.profileName)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", testCase.profileName, err)
+			continue
+		}
+
+		output := string(f.rawContent)
+
+		if output != testCase.expectedOutput {
+			t.Errorf("%s: expected:\n%q\nbut got:\n%q", testCase.profileName, testCase.expectedOutput, output)
+		}
+
+		if false == reflect.DeepEqual(f.ProfileNames(), testCase.expectedProfileNames) {
+			t.Errorf("%s: expected profiles %v but got %v", testCase.profileName, testCase.expectedProfileNames, f.ProfileNames())
+		}
+	}
+}
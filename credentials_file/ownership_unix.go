@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package credentials_file
+
+import (
+	"os"
+	"syscall"
+)
+
+// preserveOwnership gives the file the same owner and group as the existing file, where permitted.
+// Users other than root can only give files to groups they belong to, so if that's not permitted
+// (e.g. the existing file inherited its group from a setgid directory), the file keeps the user's own group.
+func preserveOwnership(filename string, existingFileInfo os.FileInfo) error {
+	stat, ok := existingFileInfo.Sys().(*syscall.Stat_t)
+	if false == ok {
+		return nil
+	}
+
+	if int(stat.Uid) == os.Getuid() && int(stat.Gid) == os.Getgid() {
+		return nil
+	}
+
+	err := os.Chown(filename, int(stat.Uid), int(stat.Gid))
+	if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.EPERM {
+		return nil
+	}
+
+	return err
+}
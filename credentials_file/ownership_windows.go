@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package credentials_file
+
+import (
+	"os"
+)
+
+// preserveOwnership does nothing on Windows, where new files inherit their permissions from the directory.
+func preserveOwnership(filename string, existingFileInfo os.FileInfo) error {
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/go-ini/ini"
 	"github.com/luhring/awsmfa/credentials"
+	"time"
 )
 
 const errFormatGettingCredentialsFromProfile = "unable to load AWS credentials from profile '%s'"
@@ -23,20 +24,24 @@ func (f *CredentialsFile) DoesProfileHavePermanentCredentials(profileName string
 func (f *CredentialsFile) GetCredentialsFromProfile(name string) (*credentials.Credentials, error) {
 	p := f.getProfile(name)
 
-	accessKeyIDItem, err := p.GetKey(keyNameForAccessKeyID)
-	accessKeyID := accessKeyIDItem.Value()
+	if p == nil {
+		return nil, fmt.Errorf(errFormatGettingCredentialsFromProfile, name)
+	}
 
+	accessKeyIDItem, err := p.GetKey(keyNameForAccessKeyID)
 	if err != nil {
 		return nil, fmt.Errorf(errFormatGettingCredentialsFromProfile, p.Name())
 	}
 
-	secretAccessKeyItem, err := p.GetKey(keyNameForSecretAccessKey)
-	secretAccessKey := secretAccessKeyItem.Value()
+	accessKeyID := accessKeyIDItem.Value()
 
+	secretAccessKeyItem, err := p.GetKey(keyNameForSecretAccessKey)
 	if err != nil {
 		return nil, fmt.Errorf(errFormatGettingCredentialsFromProfile, p.Name())
 	}
 
+	secretAccessKey := secretAccessKeyItem.Value()
+
 	sessionTokenItem, err := p.GetKey(keyNameForSessionToken)
 	sessionToken := ""
 
@@ -44,11 +49,29 @@ func (f *CredentialsFile) GetCredentialsFromProfile(name string) (*credentials.C
 		sessionToken = sessionTokenItem.Value()
 	}
 
-	return credentials.New(
+	// An unreadable expiration is treated as unknown, since AWS tools will still use the credentials.
+	var expiration time.Time
+
+	expirationItem, err := p.GetKey(keyNameForExpiration)
+
+	if err == nil {
+		expiration, _ = time.Parse(expirationFormat, expirationItem.Value())
+	}
+
+	c := credentials.NewWithExpiration(
 		accessKeyID,
 		secretAccessKey,
 		sessionToken,
-	), nil
+		expiration,
+	)
+
+	sourceItem, err := p.GetKey(keyNameForSource)
+
+	if err == nil {
+		c.Source = sourceItem.Value()
+	}
+
+	return c, nil
 }
 
 func (f *CredentialsFile) getProfile(name string) *Profile {
@@ -66,13 +89,3 @@ func (f *CredentialsFile) getProfile(name string) *Profile {
 
 	return nil
 }
-
-func createNewKeyInProfile(profile *ini.Section, keyName, keyValue string) error {
-	_, err := profile.NewKey(keyName, keyValue)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"github.com/luhring/awsmfa/credentials"
+	"io"
+	"strings"
+	"time"
+)
+
+// Shells for which 'awsmfa env' can print statements
+const (
+	shellBash       = "bash"
+	shellZsh        = "zsh"
+	shellFish       = "fish"
+	shellPowerShell = "powershell"
+)
+
+// validateShell returns an error if awsmfa doesn't know how to print statements for the shell.
+func validateShell(shell string) error {
+	switch shell {
+	case shellBash, shellZsh, shellFish, shellPowerShell:
+		return nil
+	default:
+		return fmt.Errorf("unsupported shell '%s', must be one of: %s, %s, %s, %s", shell, shellBash, shellZsh, shellFish, shellPowerShell)
+	}
+}
+
+// writeExportStatements writes statements that set the credentials' environment variables in the shell,
+// and unset any credential variables that don't apply to these credentials.
+func writeExportStatements(w io.Writer, shell string, c *credentials.Credentials) error {
+	variables := [][2]string{
+		{nameOfVariableForAccessKeyID, c.AccessKeyID},
+		{nameOfVariableForSecretAccessKey, c.SecretAccessKey},
+	}
+
+	var unsetNames []string
+
+	if c.HasSessionToken() {
+		variables = append(variables, [2]string{nameOfVariableForSessionToken, c.SessionToken})
+	} else {
+		unsetNames = append(unsetNames, nameOfVariableForSessionToken)
+	}
+
+	if c.HasExpiration() {
+		variables = append(variables, [2]string{nameOfVariableForSessionExpiration, c.Expiration.UTC().Format(time.RFC3339)})
+	} else {
+		unsetNames = append(unsetNames, nameOfVariableForSessionExpiration)
+	}
+
+	unsetNames = append(unsetNames, nameOfVariableForSecurityToken, nameOfVariableForProfile, nameOfVariableForDefaultProfile)
+
+	for _, v := range variables {
+		_, err := fmt.Fprintln(w, exportStatement(shell, v[0], v[1]))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range unsetNames {
+		_, err := fmt.Fprintln(w, unsetStatement(shell, name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeUnsetStatements writes statements that remove all credential environment variables from the shell.
+func writeUnsetStatements(w io.Writer, shell string) error {
+	names := []string{
+		nameOfVariableForAccessKeyID,
+		nameOfVariableForSecretAccessKey,
+		nameOfVariableForSessionToken,
+		nameOfVariableForSecurityToken,
+		nameOfVariableForSessionExpiration,
+	}
+
+	for _, name := range names {
+		_, err := fmt.Fprintln(w, unsetStatement(shell, name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportStatement(shell, name, value string) string {
+	switch shell {
+	case shellFish:
+		return fmt.Sprintf("set -gx %s %s;", name, quoteForFish(value))
+	case shellPowerShell:
+		return fmt.Sprintf("$Env:%s = %s", name, quoteForPowerShell(value))
+	default:
+		return fmt.Sprintf("export %s=%s", name, quoteForPOSIXShell(value))
+	}
+}
+
+func unsetStatement(shell, name string) string {
+	switch shell {
+	case shellFish:
+		return fmt.Sprintf("set -e %s;", name)
+	case shellPowerShell:
+		return fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", name)
+	default:
+		return fmt.Sprintf("unset %s", name)
+	}
+}
+
+func quoteForPOSIXShell(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func quoteForFish(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	return "'" + strings.Replace(value, "'", `\'`, -1) + "'"
+}
+
+func quoteForPowerShell(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
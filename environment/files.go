@@ -10,7 +10,12 @@ import (
 const (
 	nameOfCredentialsFile       = "credentials"
 	nameOfCredentialsFileBackup = "credentials_backup_by_awsmfa"
+	nameOfCredentialsFileLock   = "credentials_lock_by_awsmfa"
+	nameOfConfigFile            = "config"
 	nameOfAwsDirectory          = ".aws"
+	nameOfAwsmfaDirectory       = "awsmfa"
+	nameOfCacheDirectory        = "cache"
+	nameOfTOTPDirectory         = "totp"
 )
 
 func (e *Environment) DoesHaveCredentialsFile() bool {
@@ -21,6 +26,10 @@ func (e *Environment) DoesHaveCredentialsFileBackup() bool {
 	return doesFileExist(e.PathToCredentialsFileBackup())
 }
 
+func (e *Environment) DoesHaveConfigFile() bool {
+	return doesFileExist(e.PathToConfigFile())
+}
+
 func (e *Environment) PathToCredentialsFile() string {
 	return path.Join(e.pathToAwsDir(), nameOfCredentialsFile)
 }
@@ -29,6 +38,25 @@ func (e *Environment) PathToCredentialsFileBackup() string {
 	return path.Join(e.pathToAwsDir(), nameOfCredentialsFileBackup)
 }
 
+// PathToCredentialsFileLock returns the lock file that awsmfa uses to prevent concurrent runs from modifying the credentials file.
+func (e *Environment) PathToCredentialsFileLock() string {
+	return path.Join(e.pathToAwsDir(), nameOfCredentialsFileLock)
+}
+
+func (e *Environment) PathToConfigFile() string {
+	return path.Join(e.pathToAwsDir(), nameOfConfigFile)
+}
+
+// PathToCacheDirectory returns the directory where awsmfa caches temporary credentials it doesn't save to the credentials file.
+func (e *Environment) PathToCacheDirectory() string {
+	return path.Join(e.pathToAwsDir(), nameOfAwsmfaDirectory, nameOfCacheDirectory)
+}
+
+// PathToTOTPDirectory returns the directory where awsmfa records which TOTP codes it has used.
+func (e *Environment) PathToTOTPDirectory() string {
+	return path.Join(e.pathToAwsDir(), nameOfAwsmfaDirectory, nameOfTOTPDirectory)
+}
+
 func doesFileExist(pathToFile string) bool {
 	_, err := os.Stat(pathToFile)
 
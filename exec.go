@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"github.com/luhring/awsmfa/credentials"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Names of environment variables through which AWS tools receive credentials
+const (
+	nameOfVariableForAccessKeyID       = "AWS_ACCESS_KEY_ID"
+	nameOfVariableForSecretAccessKey   = "AWS_SECRET_ACCESS_KEY"
+	nameOfVariableForSessionToken      = "AWS_SESSION_TOKEN"
+	nameOfVariableForSecurityToken     = "AWS_SECURITY_TOKEN" // legacy name for AWS_SESSION_TOKEN
+	nameOfVariableForSessionExpiration = "AWS_SESSION_EXPIRATION"
+	nameOfVariableForProfile           = "AWS_PROFILE"
+	nameOfVariableForDefaultProfile    = "AWS_DEFAULT_PROFILE"
+)
+
+// Signals that awsmfa relays to the command. Interrupts from the terminal (Ctrl-C) already reach the command,
+// since it's in the same process group, so they're caught but not relayed, which would deliver them twice.
+var forwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// runWithCredentials runs the command with the credentials in its environment, and returns the command's exit code.
+// If the command is killed by a signal, the exit code is 128 plus the signal's number, as in shells.
+func runWithCredentials(commandArguments []string, c *credentials.Credentials) (int, error) {
+	command := exec.Command(commandArguments[0], commandArguments[1:]...)
+	command.Env = environmentWithCredentials(os.Environ(), c)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, append(forwardedSignals, os.Interrupt)...)
+	defer signal.Stop(signals)
+
+	err := command.Start()
+	if err != nil {
+		return 0, fmt.Errorf("unable to run command '%s': %s", commandArguments[0], err.Error())
+	}
+
+	go func() {
+		for s := range signals {
+			if s == os.Interrupt {
+				continue
+			}
+
+			_ = command.Process.Signal(s)
+		}
+	}()
+
+	err = command.Wait()
+
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if waitStatus, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				if waitStatus.Signaled() {
+					return 128 + int(waitStatus.Signal()), nil
+				}
+
+				return waitStatus.ExitStatus(), nil
+			}
+
+			return 1, nil
+		}
+
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+// environmentWithCredentials returns a copy of the environment with the credentials set,
+// and without variables that would cause AWS tools to use other credentials.
+func environmentWithCredentials(environ []string, c *credentials.Credentials) []string {
+	removedNames := []string{
+		nameOfVariableForAccessKeyID,
+		nameOfVariableForSecretAccessKey,
+		nameOfVariableForSessionToken,
+		nameOfVariableForSecurityToken,
+		nameOfVariableForSessionExpiration,
+		nameOfVariableForProfile,
+		nameOfVariableForDefaultProfile,
+	}
+
+	var result []string
+
+	for _, variable := range environ {
+		name := strings.SplitN(variable, "=", 2)[0]
+
+		if false == containsString(removedNames, name) {
+			result = append(result, variable)
+		}
+	}
+
+	result = append(result,
+		nameOfVariableForAccessKeyID+"="+c.AccessKeyID,
+		nameOfVariableForSecretAccessKey+"="+c.SecretAccessKey,
+	)
+
+	if c.HasSessionToken() {
+		result = append(result, nameOfVariableForSessionToken+"="+c.SessionToken)
+	}
+
+	if c.HasExpiration() {
+		result = append(result, nameOfVariableForSessionExpiration+"="+c.Expiration.UTC().Format(time.RFC3339))
+	}
+
+	return result
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,48 +2,128 @@ package file_coordinator
 
 import (
 	"fmt"
+	"github.com/luhring/awsmfa/credentials"
 	"github.com/luhring/awsmfa/credentials_file"
+	"strings"
 )
 
+// First line of backup files that hold one section per profile. Backup files without it were made by earlier versions of awsmfa,
+// which copied the whole credentials file.
+const backupFileHeader = "# Long-term credentials backed up by awsmfa, one profile per section\n"
+
+// BackUp saves the long-term credentials of the target profile to the backup file, in a section named after the profile.
+// Only the access key ID and secret access key are backed up, and other profiles' backups are left intact, so that restoring one profile never
+// undoes changes made to another since the backup was taken.
 func (c *Coordinator) BackUp() error {
-	if existingBackup, err := c.getCredentialsFileBackup(); err == nil {
-		err = existingBackup.Delete()
-		if err != nil {
-			return err
-		}
+	credentialsFile, err := c.getCredentialsFile()
+	if err != nil {
+		return err
 	}
 
-	credentialsFile, err := c.getCredentialsFile()
+	longTermCredentials, err := credentialsFile.GetCredentialsFromProfile(c.TargetProfileName)
 	if err != nil {
 		return err
 	}
 
-	newBackup, err := credentials_file.NewFromConfiguration(credentialsFile.Configuration, c.Env.PathToCredentialsFileBackup())
+	if false == longTermCredentials.ArePermanent() {
+		return fmt.Errorf("'%s' profile doesn't contain long-term credentials to back up", c.TargetProfileName)
+	}
+
+	backup, err := c.getCredentialsFileBackupForProfile(c.TargetProfileName)
 	if err != nil {
 		return err
 	}
 
-	err = newBackup.Save()
+	err = backup.MergeCredentialsIntoProfile(credentials.New(longTermCredentials.AccessKeyID, longTermCredentials.SecretAccessKey, ""), c.TargetProfileName)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Backed up credentials file to %s\n", newBackup.Filename)
+	err = c.save(backup)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Backed up long-term credentials of '%s' profile to %s\n", c.TargetProfileName, backup.Filename)
 
 	return nil
 }
 
+// BackUpPermanentCredentialsIfPresent backs up the target profile's long-term credentials before they're replaced by session credentials.
 func (c *Coordinator) BackUpPermanentCredentialsIfPresent() error {
-	if c.Env.DoesHaveCredentialsFile() {
+	if c.hasCredentialsFile() {
 		credentialsFile, err := c.getCredentialsFile()
 		if err != nil {
 			return err
 		}
 
-		if credentialsFile.DoesProfileHavePermanentCredentials(c.SelectedProfileName) {
+		if credentialsFile.DoesProfileHavePermanentCredentials(c.TargetProfileName) {
 			return c.BackUp()
 		}
 	}
 
 	return nil
 }
+
+// findBackedUpCredentials returns the long-term credentials backed up for the profile.
+func findBackedUpCredentials(backup *credentials_file.CredentialsFile, profileName string) (*credentials.Credentials, error) {
+	backedUpCredentials, err := backup.GetCredentialsFromProfile(profileName)
+	if err != nil || false == backedUpCredentials.ArePermanent() {
+		return nil, fmt.Errorf("unable to find backed-up long-term credentials for '%s' profile", profileName)
+	}
+
+	return credentials.New(backedUpCredentials.AccessKeyID, backedUpCredentials.SecretAccessKey, ""), nil
+}
+
+// removeBackup removes the profile's section from the backup file, and removes the backup file once no profiles remain.
+func (c *Coordinator) removeBackup(backup *credentials_file.CredentialsFile, profileName string) error {
+	err := backup.RemoveProfile(profileName)
+	if err != nil {
+		return err
+	}
+
+	if len(backup.ProfileNames()) == 0 {
+		return c.files().Remove(backup.Filename)
+	}
+
+	return c.save(backup)
+}
+
+// getCredentialsFileBackupForProfile returns the backup file (or a new, empty one), ready for the profile's credentials to be backed up or restored.
+// A backup made by an earlier version of awsmfa is migrated by dropping every profile except this one, since the other profiles'
+// credentials may well have changed since the whole file was copied. The migration takes effect once the backup file is saved.
+func (c *Coordinator) getCredentialsFileBackupForProfile(profileName string) (*credentials_file.CredentialsFile, error) {
+	migratedBackup, err := credentials_file.NewFromContent([]byte(backupFileHeader), c.Env.PathToCredentialsFileBackup())
+	if err != nil {
+		return nil, err
+	}
+
+	if false == c.hasCredentialsFileBackup() {
+		return migratedBackup, nil
+	}
+
+	backup, err := c.getCredentialsFileBackup()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := backup.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(string(content), strings.TrimSpace(backupFileHeader)) {
+		return backup, nil
+	}
+
+	// Profiles of the legacy backup can also hold session credentials, which aren't worth keeping.
+	legacyCredentials, err := backup.GetCredentialsFromProfile(profileName)
+	if err == nil && legacyCredentials.ArePermanent() {
+		err = migratedBackup.MergeCredentialsIntoProfile(credentials.New(legacyCredentials.AccessKeyID, legacyCredentials.SecretAccessKey, ""), profileName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return migratedBackup, nil
+}
@@ -0,0 +1,133 @@
+package file_coordinator
+
+import (
+	"github.com/luhring/awsmfa/environment"
+	"testing"
+)
+
+func TestPerProfileBackups(t *testing.T) {
+	env := &environment.Environment{}
+	credentialsPath := env.PathToCredentialsFile()
+	backupPath := env.PathToCredentialsFileBackup()
+
+	t.Run("backing up a profile leaves other profiles' backups intact", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{
+			credentialsPath: "[work]\naws_access_key_id = AKIAWORK\naws_secret_access_key = work-secret\nregion = eu-west-1\n",
+			backupPath:      backupFileHeader + "\n[personal]\naws_access_key_id = AKIAPERSONAL\naws_secret_access_key = personal-secret\n",
+		})
+		c.SelectedProfileName = "work"
+		c.TargetProfileName = "work"
+
+		err := c.BackUpPermanentCredentialsIfPresent()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedBackup := backupFileHeader + "\n[personal]\naws_access_key_id = AKIAPERSONAL\naws_secret_access_key = personal-secret\n\n[work]\naws_access_key_id = AKIAWORK\naws_secret_access_key = work-secret\n"
+
+		if fs.files[backupPath] != expectedBackup {
+			t.Errorf("expected backup:\n%q\nbut got:\n%q", expectedBackup, fs.files[backupPath])
+		}
+	})
+
+	t.Run("backing up a profile migrates a legacy backup", func(t *testing.T) {
+		// The backup was made by an earlier version of awsmfa, which copied the whole file, so its 'personal' keys may be stale.
+		c, fs := newTestCoordinator(map[string]string{
+			credentialsPath: "[work]\naws_access_key_id = AKIAWORK\naws_secret_access_key = work-secret\n",
+			backupPath:      "[work]\naws_access_key_id = AKIAOLDWORK\naws_secret_access_key = old-work-secret\nregion = eu-west-1\n\n[personal]\naws_access_key_id = AKIAPERSONAL\naws_secret_access_key = personal-secret\n",
+		})
+		c.SelectedProfileName = "work"
+		c.TargetProfileName = "work"
+
+		err := c.BackUp()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedBackup := backupFileHeader + "\n[work]\naws_access_key_id = AKIAWORK\naws_secret_access_key = work-secret\n"
+
+		if fs.files[backupPath] != expectedBackup {
+			t.Errorf("expected backup:\n%q\nbut got:\n%q", expectedBackup, fs.files[backupPath])
+		}
+	})
+
+	t.Run("restoring a profile from a legacy backup doesn't clobber changes to other profiles", func(t *testing.T) {
+		// The backup was made by an earlier version of awsmfa, which copied the whole file. Since then, the 'personal' keys were rotated.
+		c, fs := newTestCoordinator(map[string]string{
+			credentialsPath: "[work]\naws_access_key_id = ASIAWORK\naws_secret_access_key = session-secret\naws_session_token = session-token\nregion = eu-west-1\n\n[personal]\naws_access_key_id = AKIAROTATED\naws_secret_access_key = rotated-secret\n",
+			backupPath:      "[work]\naws_access_key_id = AKIAWORK\naws_secret_access_key = work-secret\n\n[personal]\naws_access_key_id = AKIAPERSONAL\naws_secret_access_key = personal-secret\n",
+		})
+		c.SelectedProfileName = "work"
+		c.TargetProfileName = "work"
+
+		err := c.Restore()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedCredentials := "[work]\naws_access_key_id = AKIAWORK\naws_secret_access_key = work-secret\nregion = eu-west-1\n\n[personal]\naws_access_key_id = AKIAROTATED\naws_secret_access_key = rotated-secret\n"
+
+		if fs.files[credentialsPath] != expectedCredentials {
+			t.Errorf("expected credentials file:\n%q\nbut got:\n%q", expectedCredentials, fs.files[credentialsPath])
+		}
+
+		// Only the 'work' profile survives the migration, so once it's restored, nothing remains to be backed up.
+		if fs.Exists(backupPath) {
+			t.Error("expected backup file to be removed")
+		}
+	})
+
+	t.Run("restoring a profile leaves other profiles' backups intact", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{
+			credentialsPath: temporaryCredentialsFile,
+			backupPath:      backupFileHeader + "\n" + permanentCredentialsFile + "\n[personal]\naws_access_key_id = AKIAPERSONAL\naws_secret_access_key = personal-secret\n",
+		})
+
+		err := c.Restore()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedBackup := backupFileHeader + "\n[personal]\naws_access_key_id = AKIAPERSONAL\naws_secret_access_key = personal-secret\n"
+
+		if fs.files[backupPath] != expectedBackup {
+			t.Errorf("expected backup:\n%q\nbut got:\n%q", expectedBackup, fs.files[backupPath])
+		}
+	})
+
+	t.Run("restoring the last backed-up profile removes the backup file", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{
+			credentialsPath: temporaryCredentialsFile,
+			backupPath:      permanentCredentialsFile,
+		})
+
+		err := c.Restore()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fs.files[credentialsPath] != permanentCredentialsFile {
+			t.Errorf("expected credentials file:\n%q\nbut got:\n%q", permanentCredentialsFile, fs.files[credentialsPath])
+		}
+
+		if fs.Exists(backupPath) {
+			t.Error("expected backup file to be removed")
+		}
+	})
+
+	t.Run("restoring a profile without a backup fails", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{
+			credentialsPath: temporaryCredentialsFile,
+			backupPath:      "[personal]\naws_access_key_id = AKIAPERSONAL\naws_secret_access_key = personal-secret\n",
+		})
+
+		err := c.Restore()
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		if fs.files[credentialsPath] != temporaryCredentialsFile {
+			t.Error("expected credentials file to be unchanged")
+		}
+	})
+}
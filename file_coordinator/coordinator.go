@@ -2,16 +2,26 @@ package file_coordinator
 
 import (
 	"errors"
+	"fmt"
+	"github.com/luhring/awsmfa/credentials"
 	"github.com/luhring/awsmfa/credentials_file"
 	"github.com/luhring/awsmfa/environment"
+	"os"
+	"time"
 )
 
 type Coordinator struct {
 	Env                 *environment.Environment
-	SelectedProfileName string
+	SelectedProfileName string // profile holding the long-term credentials
+	TargetProfileName   string // profile that receives the temporary session credentials
+	Layout              Layout // where long-term credentials are kept, LayoutBackupFile unless set
+
+	fs          fileSystem   // if nil, the real file system is used
+	lock        *fileLock    // held while the credentials file is being modified, see Lock
+	transaction *transaction // in progress between Begin and Commit or Rollback
 }
 
-func New(env *environment.Environment, selectedProfile string) (*Coordinator, error) {
+func New(env *environment.Environment, selectedProfile, targetProfile string) (*Coordinator, error) {
 	if env == nil {
 		return nil, errors.New("env parameter cannot be nil")
 	}
@@ -20,24 +30,149 @@ func New(env *environment.Environment, selectedProfile string) (*Coordinator, er
 		return nil, errors.New("selectedProfile parameter cannot have zero length")
 	}
 
+	if len(targetProfile) == 0 {
+		return nil, errors.New("targetProfile parameter cannot have zero length")
+	}
+
 	return &Coordinator{
-		env,
-		selectedProfile,
+		Env:                 env,
+		SelectedProfileName: selectedProfile,
+		TargetProfileName:   targetProfile,
+		Layout:              LayoutBackupFile,
+		fs:                  osFileSystem{},
 	}, nil
 }
 
+// FindReusableSession returns the session credentials saved to the profile, if they were obtained from the given source
+// (see authenticator.SessionSources) and remain valid for at least minimumRemainingLifetime.
+// Session credentials without a recorded expiration or source are never considered reusable.
+func (c *Coordinator) FindReusableSession(profileName, source string, minimumRemainingLifetime time.Duration, now time.Time) (*credentials.Credentials, bool) {
+	if false == c.hasCredentialsFile() {
+		return nil, false
+	}
+
+	credentialsFile, err := c.getCredentialsFile()
+	if err != nil {
+		return nil, false
+	}
+
+	sessionCredentials, err := credentialsFile.GetCredentialsFromProfile(profileName)
+	if err != nil || false == sessionCredentials.HasSessionToken() || sessionCredentials.Source != source {
+		return nil, false
+	}
+
+	if sessionCredentials.RemainingLifetime(now) <= minimumRemainingLifetime {
+		return nil, false
+	}
+
+	return sessionCredentials, true
+}
+
+// SaveCredentials merges the credentials into the profile, leaving the rest of the credentials file intact.
+// During a transaction, the credentials are staged, and are only written to disk by Commit.
+func (c *Coordinator) SaveCredentials(creds *credentials.Credentials, profileName string) error {
+	var credentialsFile *credentials_file.CredentialsFile
+
+	if c.transaction != nil && c.transaction.staged != nil {
+		credentialsFile = c.transaction.staged
+	} else {
+		var err error
+
+		credentialsFile, err = c.getCredentialsFileOrEmpty()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := credentialsFile.MergeCredentialsIntoProfile(creds, profileName)
+	if err != nil {
+		return err
+	}
+
+	if c.transaction != nil {
+		c.transaction.staged = credentialsFile
+		return nil
+	}
+
+	return c.save(credentialsFile)
+}
+
+// CheckForLongTermCredentials returns an error if any of the profiles holds long-term credentials.
+// Only the target profile's long-term credentials are backed up, so saving session credentials to any other profile must not overwrite them.
+func (c *Coordinator) CheckForLongTermCredentials(profileNames ...string) error {
+	if false == c.hasCredentialsFile() {
+		return nil
+	}
+
+	credentialsFile, err := c.getCredentialsFile()
+	if err != nil {
+		return err
+	}
+
+	for _, profileName := range profileNames {
+		if credentialsFile.DoesProfileHavePermanentCredentials(profileName) {
+			return fmt.Errorf("'%s' profile holds long-term credentials, which would be overwritten (use a different profile for the role's credentials)", profileName)
+		}
+	}
+
+	return nil
+}
+
+func (c *Coordinator) files() fileSystem {
+	if c.fs == nil {
+		return osFileSystem{}
+	}
+
+	return c.fs
+}
+
+func (c *Coordinator) hasCredentialsFile() bool {
+	return c.files().Exists(c.Env.PathToCredentialsFile())
+}
+
+func (c *Coordinator) hasCredentialsFileBackup() bool {
+	return c.files().Exists(c.Env.PathToCredentialsFileBackup())
+}
+
 func (c *Coordinator) getCredentialsFile() (*credentials_file.CredentialsFile, error) {
-	if false == c.Env.DoesHaveCredentialsFile() {
+	if false == c.hasCredentialsFile() {
 		return nil, errors.New("unable to find credentials file")
 	}
 
-	return credentials_file.NewFromDisk(c.Env.PathToCredentialsFile())
+	return c.load(c.Env.PathToCredentialsFile())
+}
+
+func (c *Coordinator) getCredentialsFileOrEmpty() (*credentials_file.CredentialsFile, error) {
+	credentialsFile, err := c.load(c.Env.PathToCredentialsFile())
+	if os.IsNotExist(err) {
+		return credentials_file.NewEmpty(c.Env.PathToCredentialsFile()), nil
+	}
+
+	return credentialsFile, err
 }
 
 func (c *Coordinator) getCredentialsFileBackup() (*credentials_file.CredentialsFile, error) {
-	if false == c.Env.DoesHaveCredentialsFileBackup() {
+	if false == c.hasCredentialsFileBackup() {
 		return nil, errors.New("unable to find backup of credentials file")
 	}
 
-	return credentials_file.NewFromDisk(c.Env.PathToCredentialsFileBackup())
+	return c.load(c.Env.PathToCredentialsFileBackup())
+}
+
+func (c *Coordinator) load(filename string) (*credentials_file.CredentialsFile, error) {
+	content, err := c.files().ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials_file.NewFromContent(content, filename)
+}
+
+func (c *Coordinator) save(f *credentials_file.CredentialsFile) error {
+	content, err := f.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return c.files().WriteFile(f.Filename, content)
 }
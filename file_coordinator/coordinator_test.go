@@ -0,0 +1,79 @@
+package file_coordinator
+
+import (
+	"github.com/luhring/awsmfa/credentials"
+	"github.com/luhring/awsmfa/environment"
+	"testing"
+	"time"
+)
+
+func TestFindReusableSession(t *testing.T) {
+	const (
+		sessionSource = "session credentials using 'default' profile"
+		roleSource    = "credentials for role 'arn:aws:iam::222222222222:role/Admin' using 'default' profile"
+	)
+
+	now := time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	sessionCredentials := credentials.NewWithExpiration("ASIANEW", "new-secret", "new-token", now.Add(6*time.Hour))
+	sessionCredentials.Source = sessionSource
+
+	c, _ := newTestCoordinator(map[string]string{})
+
+	err := c.SaveCredentials(sessionCredentials, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name                     string
+		source                   string
+		minimumRemainingLifetime time.Duration
+		expectedReusable         bool
+	}{
+		{"same source", sessionSource, 15 * time.Minute, true},
+		{"different role requested", roleSource, 15 * time.Minute, false},
+		{"expiring too soon", sessionSource, 7 * time.Hour, false},
+	}
+
+	for _, testCase := range testCases {
+		_, isReusable := c.FindReusableSession("default", testCase.source, testCase.minimumRemainingLifetime, now)
+
+		if isReusable != testCase.expectedReusable {
+			t.Errorf("%s: expected reusable: %t but got %t", testCase.name, testCase.expectedReusable, isReusable)
+		}
+	}
+
+	t.Run("session credentials without a recorded source aren't reused", func(t *testing.T) {
+		c, _ := newTestCoordinator(map[string]string{
+			(&environment.Environment{}).PathToCredentialsFile(): "[default]\naws_access_key_id = ASIAOLD\naws_secret_access_key = old-secret\naws_session_token = old-token\nx_security_token_expires = 2019-01-01T18:00:00Z\n",
+		})
+
+		if _, isReusable := c.FindReusableSession("default", sessionSource, 15*time.Minute, now); isReusable {
+			t.Error("expected session credentials not to be reused")
+		}
+	})
+}
+
+func TestCheckForLongTermCredentials(t *testing.T) {
+	c, _ := newTestCoordinator(map[string]string{
+		(&environment.Environment{}).PathToCredentialsFile(): "[prod]\naws_access_key_id = AKIAPROD\naws_secret_access_key = prod-secret\n\n[member-a]\naws_access_key_id = ASIAOLD\naws_secret_access_key = old-secret\naws_session_token = old-token\n",
+	})
+
+	testCases := []struct {
+		profileNames []string
+		expectError  bool
+	}{
+		{[]string{"member-a", "prod"}, true},
+		{[]string{"member-a", "member-b"}, false},
+		{nil, false},
+	}
+
+	for _, testCase := range testCases {
+		err := c.CheckForLongTermCredentials(testCase.profileNames...)
+
+		if testCase.expectError != (err != nil) {
+			t.Errorf("expected error: %t but got %v -- profiles were %v", testCase.expectError, err, testCase.profileNames)
+		}
+	}
+}
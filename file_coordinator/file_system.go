@@ -0,0 +1,34 @@
+package file_coordinator
+
+import (
+	"github.com/luhring/awsmfa/credentials_file"
+	"io/ioutil"
+	"os"
+)
+
+// fileSystem is the part of the file system that the coordinator reads and modifies. Tests replace it with an in-memory fake.
+type fileSystem interface {
+	ReadFile(filename string) ([]byte, error)
+	WriteFile(filename string, content []byte) error
+	Remove(filename string) error
+	Exists(filename string) bool
+}
+
+type osFileSystem struct{}
+
+func (osFileSystem) ReadFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
+func (osFileSystem) WriteFile(filename string, content []byte) error {
+	return credentials_file.WriteFileAtomically(filename, content)
+}
+
+func (osFileSystem) Remove(filename string) error {
+	return os.Remove(filename)
+}
+
+func (osFileSystem) Exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,165 @@
+package file_coordinator
+
+import (
+	"fmt"
+	"github.com/luhring/awsmfa/credentials"
+	"github.com/luhring/awsmfa/environment"
+)
+
+// Layout determines where a profile's long-term credentials are kept while it holds session credentials.
+type Layout string
+
+const (
+	// LayoutBackupFile backs up the long-term credentials to a separate file and restores them when needed (the default).
+	LayoutBackupFile Layout = "backup-file"
+
+	// LayoutLongTermProfile moves the long-term credentials to a companion profile (e.g. 'work-long-term' for 'work'),
+	// from which STS calls are always made.
+	LayoutLongTermProfile Layout = "long-term-profile"
+)
+
+const suffixOfLongTermProfileName = "-long-term"
+
+// ParseLayout returns the layout with the given name. An empty name means the default layout.
+func ParseLayout(name string) (Layout, error) {
+	switch Layout(name) {
+	case "", LayoutBackupFile:
+		return LayoutBackupFile, nil
+	case LayoutLongTermProfile:
+		return LayoutLongTermProfile, nil
+	default:
+		return "", fmt.Errorf("unknown layout '%s' (expected '%s' or '%s')", name, LayoutBackupFile, LayoutLongTermProfile)
+	}
+}
+
+// ValidateLayout checks that the layout applies to the coordinator's profiles. When session credentials are saved to a separate target profile,
+// the selected profile keeps its long-term credentials, so there's no need for a companion profile.
+func (c *Coordinator) ValidateLayout() error {
+	if c.Layout == LayoutLongTermProfile && c.TargetProfileName != c.SelectedProfileName {
+		return fmt.Errorf("the '%s' layout can't be combined with a separate target profile ('%s'), since '%s' profile already keeps its long-term credentials", LayoutLongTermProfile, c.TargetProfileName, c.SelectedProfileName)
+	}
+
+	return nil
+}
+
+// LongTermProfileName returns the name of the companion profile that holds the selected profile's long-term credentials in the long-term-profile layout.
+func (c *Coordinator) LongTermProfileName() string {
+	return c.SelectedProfileName + suffixOfLongTermProfileName
+}
+
+// ProfileNamesInUse returns the names of the profiles that the coordinator reads from or writes to,
+// including the companion profile in the long-term-profile layout.
+func (c *Coordinator) ProfileNamesInUse() []string {
+	profileNames := []string{c.SelectedProfileName, c.TargetProfileName}
+
+	if c.Layout == LayoutLongTermProfile {
+		profileNames = append(profileNames, c.LongTermProfileName())
+	}
+
+	return profileNames
+}
+
+// SourceProfileName returns the profile whose long-term credentials should be used to call STS.
+// In the long-term-profile layout, that's the companion profile, once it holds long-term credentials.
+func (c *Coordinator) SourceProfileName() string {
+	if c.Layout != LayoutLongTermProfile || false == c.hasCredentialsFile() {
+		return c.SelectedProfileName
+	}
+
+	credentialsFile, err := c.getCredentialsFile()
+	if err != nil || false == credentialsFile.DoesProfileHavePermanentCredentials(c.LongTermProfileName()) {
+		return c.SelectedProfileName
+	}
+
+	return c.LongTermProfileName()
+}
+
+// PrepareLongTermCredentials makes sure that long-term credentials are available to call STS, and that they're preserved once session credentials are saved.
+func (c *Coordinator) PrepareLongTermCredentials() error {
+	err := c.ValidateLayout()
+	if err != nil {
+		return err
+	}
+
+	if c.Layout == LayoutLongTermProfile {
+		return c.moveToLongTermProfile()
+	}
+
+	c.RestorePermanentCredentialsIfAppropriate()
+
+	return c.BackUpPermanentCredentialsIfPresent()
+}
+
+// moveToLongTermProfile copies the selected profile's long-term credentials to its companion profile.
+// This happens on the first run, and again whenever the selected profile holds long-term credentials (e.g. after keys were rotated),
+// so that the companion profile always has the newest keys.
+func (c *Coordinator) moveToLongTermProfile() error {
+	credentialsFile, err := c.getCredentialsFileOrEmpty()
+	if err != nil {
+		return err
+	}
+
+	longTermProfileName := c.LongTermProfileName()
+
+	if false == credentialsFile.DoesProfileHavePermanentCredentials(c.SelectedProfileName) {
+		if credentialsFile.DoesProfileHavePermanentCredentials(longTermProfileName) || environment.WillEnvironmentVariablesPreemptUseOfCredentialsFile() {
+			return nil
+		}
+
+		return fmt.Errorf("unable to find long-term credentials in '%s' or '%s' profile", c.SelectedProfileName, longTermProfileName)
+	}
+
+	longTermCredentials, err := credentialsFile.GetCredentialsFromProfile(c.SelectedProfileName)
+	if err != nil {
+		return err
+	}
+
+	err = credentialsFile.MergeCredentialsIntoProfile(credentials.New(longTermCredentials.AccessKeyID, longTermCredentials.SecretAccessKey, ""), longTermProfileName)
+	if err != nil {
+		return err
+	}
+
+	err = c.save(credentialsFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved long-term credentials of '%s' profile to '%s' profile\n", c.SelectedProfileName, longTermProfileName)
+
+	return nil
+}
+
+// restoreFromLongTermProfile copies the long-term credentials from the companion profile back into the selected profile.
+// The companion profile is kept, so that it's ready for the next login.
+func (c *Coordinator) restoreFromLongTermProfile() error {
+	credentialsFile, err := c.getCredentialsFileOrEmpty()
+	if err != nil {
+		return err
+	}
+
+	if credentialsFile.DoesProfileHavePermanentCredentials(c.SelectedProfileName) {
+		fmt.Printf("'%s' profile already contains permanent credentials\n", c.SelectedProfileName)
+		return nil
+	}
+
+	longTermProfileName := c.LongTermProfileName()
+
+	longTermCredentials, err := credentialsFile.GetCredentialsFromProfile(longTermProfileName)
+	if err != nil || false == longTermCredentials.ArePermanent() {
+		return fmt.Errorf("unable to find long-term credentials in '%s' profile", longTermProfileName)
+	}
+
+	err = credentialsFile.MergeCredentialsIntoProfile(credentials.New(longTermCredentials.AccessKeyID, longTermCredentials.SecretAccessKey, ""), c.SelectedProfileName)
+	if err != nil {
+		return err
+	}
+
+	err = c.save(credentialsFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored long-term credentials of '%s' profile from '%s' profile\n", c.SelectedProfileName, longTermProfileName)
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package file_coordinator
+
+import (
+	"github.com/luhring/awsmfa/credentials"
+	"github.com/luhring/awsmfa/environment"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLayout(t *testing.T) {
+	testCases := []struct {
+		name           string
+		expectedLayout Layout
+		expectError    bool
+	}{
+		{"", LayoutBackupFile, false},
+		{"backup-file", LayoutBackupFile, false},
+		{"long-term-profile", LayoutLongTermProfile, false},
+		{"long-term", "", true},
+	}
+
+	for _, testCase := range testCases {
+		layout, err := ParseLayout(testCase.name)
+
+		if testCase.expectError != (err != nil) {
+			t.Errorf("%q: expected error: %t but got %v", testCase.name, testCase.expectError, err)
+			continue
+		}
+
+		if layout != testCase.expectedLayout {
+			t.Errorf("%q: expected layout %q but got %q", testCase.name, testCase.expectedLayout, layout)
+		}
+	}
+}
+
+func TestProfileNamesInUse(t *testing.T) {
+	testCases := []struct {
+		layout               Layout
+		selectedProfileName  string
+		targetProfileName    string
+		expectedProfileNames []string
+	}{
+		{LayoutBackupFile, "work", "work", []string{"work", "work"}},
+		{LayoutBackupFile, "work", "work-session", []string{"work", "work-session"}},
+		{LayoutLongTermProfile, "work", "work", []string{"work", "work", "work-long-term"}},
+	}
+
+	for _, testCase := range testCases {
+		c := &Coordinator{Layout: testCase.layout, SelectedProfileName: testCase.selectedProfileName, TargetProfileName: testCase.targetProfileName}
+
+		profileNames := c.ProfileNamesInUse()
+
+		if false == reflect.DeepEqual(profileNames, testCase.expectedProfileNames) {
+			t.Errorf("expected %v but got %v -- layout was %q", testCase.expectedProfileNames, profileNames, testCase.layout)
+		}
+	}
+}
+
+func TestLongTermProfileLayout(t *testing.T) {
+	if environment.WillEnvironmentVariablesPreemptUseOfCredentialsFile() {
+		t.Skip("the credentials file isn't used when AWS environment variables are set")
+	}
+
+	env := &environment.Environment{}
+	credentialsPath := env.PathToCredentialsFile()
+	backupPath := env.PathToCredentialsFileBackup()
+	sessionCredentials := credentials.NewWithExpiration("ASIANEW", "new-secret", "new-token", time.Date(2019, 1, 1, 18, 0, 0, 0, time.UTC))
+
+	const (
+		sessionContent  = "[default]\naws_access_key_id = ASIANEW\naws_secret_access_key = new-secret\naws_session_token = new-token\nx_security_token_expires = 2019-01-01T18:00:00Z\n"
+		longTermContent = "\n[default-long-term]\naws_access_key_id = AKIAPERMANENT\naws_secret_access_key = permanent-secret\n"
+	)
+
+	t.Run("first login moves long-term credentials to the companion profile", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{credentialsPath: permanentCredentialsFile})
+		c.Layout = LayoutLongTermProfile
+
+		if c.SourceProfileName() != "default" {
+			t.Errorf("expected STS calls to use 'default' profile before the first login but got '%s'", c.SourceProfileName())
+		}
+
+		err := c.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.PrepareLongTermCredentials()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.SourceProfileName() != "default-long-term" {
+			t.Errorf("expected STS calls to use 'default-long-term' profile but got '%s'", c.SourceProfileName())
+		}
+
+		err = c.SaveCredentials(sessionCredentials, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedContent := sessionContent + longTermContent
+
+		if fs.files[credentialsPath] != expectedContent {
+			t.Errorf("expected credentials file:\n%q\nbut got:\n%q", expectedContent, fs.files[credentialsPath])
+		}
+
+		if fs.Exists(backupPath) {
+			t.Error("expected no backup file")
+		}
+	})
+
+	t.Run("later logins use the companion profile", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{credentialsPath: temporaryCredentialsFile + longTermContent})
+		c.Layout = LayoutLongTermProfile
+
+		err := c.PrepareLongTermCredentials()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fs.files[credentialsPath] != temporaryCredentialsFile+longTermContent {
+			t.Error("expected credentials file to be unchanged")
+		}
+
+		if c.SourceProfileName() != "default-long-term" {
+			t.Errorf("expected STS calls to use 'default-long-term' profile but got '%s'", c.SourceProfileName())
+		}
+	})
+
+	t.Run("restore copies long-term credentials back", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{credentialsPath: sessionContent + longTermContent})
+		c.Layout = LayoutLongTermProfile
+
+		err := c.Restore()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedContent := permanentCredentialsFile + longTermContent
+
+		if fs.files[credentialsPath] != expectedContent {
+			t.Errorf("expected credentials file:\n%q\nbut got:\n%q", expectedContent, fs.files[credentialsPath])
+		}
+	})
+
+	t.Run("rollback undoes the move", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{credentialsPath: permanentCredentialsFile})
+		c.Layout = LayoutLongTermProfile
+
+		err := c.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.PrepareLongTermCredentials()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.Rollback()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fs.files[credentialsPath] != permanentCredentialsFile {
+			t.Error("expected credentials file to be unchanged")
+		}
+	})
+
+	t.Run("a separate target profile is rejected", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{credentialsPath: permanentCredentialsFile + longTermContent})
+		c.Layout = LayoutLongTermProfile
+		c.TargetProfileName = "default-mfa"
+
+		err := c.Restore()
+		if err == nil {
+			t.Error("expected an error when restoring")
+		}
+
+		err = c.PrepareLongTermCredentials()
+		if err == nil {
+			t.Error("expected an error when logging in")
+		}
+
+		if fs.files[credentialsPath] != permanentCredentialsFile+longTermContent {
+			t.Error("expected credentials file to be unchanged")
+		}
+	})
+
+	t.Run("missing long-term credentials are reported", func(t *testing.T) {
+		c, _ := newTestCoordinator(map[string]string{credentialsPath: temporaryCredentialsFile})
+		c.Layout = LayoutLongTermProfile
+
+		err := c.PrepareLongTermCredentials()
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package file_coordinator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultLockTimeout is how long to wait for another run of awsmfa to finish modifying the credentials file.
+const DefaultLockTimeout = 10 * time.Second
+
+// Interval between attempts to acquire a lock held by another process
+const lockRetryInterval = 100 * time.Millisecond
+
+// Lock acquires an advisory lock on the credentials file, which should be held for the whole sequence of reading,
+// backing up, and saving the credentials file, so that concurrent runs of awsmfa don't lose each other's changes.
+// The lock is released when the process exits.
+func (c *Coordinator) Lock(timeout time.Duration) error {
+	if c.lock != nil {
+		return errors.New("credentials file is already locked")
+	}
+
+	lock, err := acquireLock(c.Env.PathToCredentialsFileLock(), timeout)
+	if err != nil {
+		return err
+	}
+
+	c.lock = lock
+
+	return nil
+}
+
+// fileLock is an advisory, exclusive lock on a lock file.
+type fileLock struct {
+	file *os.File
+}
+
+func acquireLock(path string, timeout time.Duration) (*fileLock, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open lock file %s: %s", path, err.Error())
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		isLocked, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("unable to lock %s: %s", path, err.Error())
+		}
+
+		if isLocked {
+			return &fileLock{file: file}, nil
+		}
+
+		if time.Now().After(deadline) {
+			_ = file.Close()
+			return nil, fmt.Errorf("another awsmfa is running and modifying the credentials file (waited %s for %s), try again once it has finished", timeout, path)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,43 @@
+package file_coordinator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "awsmfa-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials_lock_by_awsmfa")
+
+	first, err := acquireLock(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second attempt opens the lock file separately, just as another awsmfa process would.
+	_, err = acquireLock(path, 200*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected an error while the lock is held")
+	}
+
+	// The lock is released once the file holding it is closed, as happens when the process exits.
+	err = first.file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := acquireLock(path, time.Second)
+	if err != nil {
+		t.Fatalf("expected to acquire the released lock: %v", err)
+	}
+
+	_ = second.file.Close()
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package file_coordinator
+
+import (
+	"os"
+	"syscall"
+)
+
+// tryLockFile attempts to acquire an exclusive flock on the file without blocking, and reports whether it succeeded.
+func tryLockFile(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,47 @@
+//go:build windows
+// +build windows
+
+package file_coordinator
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Flags and error codes of LockFileEx
+const (
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+
+	errorLockViolation syscall.Errno = 33
+)
+
+var (
+	kernel32       = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx = kernel32.NewProc("LockFileEx")
+)
+
+// tryLockFile attempts to acquire an exclusive lock on the file without blocking, and reports whether it succeeded.
+func tryLockFile(file *os.File) (bool, error) {
+	var overlapped syscall.Overlapped
+
+	result, _, err := procLockFileEx.Call(
+		file.Fd(),
+		uintptr(lockfileExclusiveLock|lockfileFailImmediately),
+		0,
+		1,
+		0,
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+
+	if result != 0 {
+		return true, nil
+	}
+
+	if err == errorLockViolation {
+		return false, nil
+	}
+
+	return false, err
+}
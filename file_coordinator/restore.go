@@ -3,55 +3,63 @@ package file_coordinator
 import (
 	"errors"
 	"fmt"
-	"github.com/luhring/awsmfa/credentials_file"
 	"github.com/luhring/awsmfa/environment"
 )
 
+// Restore copies the long-term credentials back into the target profile (which is also the selected profile in the long-term-profile layout),
+// from the backup file or the companion profile depending on the layout.
+// The other profiles of the credentials file are left intact.
 func (c *Coordinator) Restore() error {
-	if c.Env.DoesHaveCredentialsFile() {
-		credentialsFile, err := c.getCredentialsFile()
-
-		if err != nil {
-			return err
-		}
+	err := c.ValidateLayout()
+	if err != nil {
+		return err
+	}
 
-		if credentialsFile.DoesProfileHavePermanentCredentials(c.SelectedProfileName) {
-			fmt.Printf("'%s' profile already contains permanent credentials\n", c.SelectedProfileName)
-			return nil
-		}
+	if c.Layout == LayoutLongTermProfile {
+		return c.restoreFromLongTermProfile()
+	}
 
-		// credentials file exists but has temporary credentials, so there's no longer a use for this file
+	return c.restoreProfile(c.TargetProfileName)
+}
 
-		err = credentialsFile.Delete()
-		if err != nil {
-			return err
-		}
+func (c *Coordinator) restoreProfile(profileName string) error {
+	credentialsFile, err := c.getCredentialsFileOrEmpty()
+	if err != nil {
+		return err
 	}
 
-	// credentials file doesn't exist
+	if credentialsFile.DoesProfileHavePermanentCredentials(profileName) {
+		fmt.Printf("'%s' profile already contains permanent credentials\n", profileName)
+		return nil
+	}
 
-	if c.Env.DoesHaveCredentialsFileBackup() {
-		backup, err := c.getCredentialsFileBackup()
-		if err != nil {
-			return err
-		}
+	if false == c.hasCredentialsFileBackup() {
+		return errors.New("unable to find original credentials")
+	}
 
-		newCredentialsFile, err := credentials_file.NewFromConfiguration(backup.Configuration, c.Env.PathToCredentialsFile())
-		if err != nil {
-			return err
-		}
+	backup, err := c.getCredentialsFileBackupForProfile(profileName)
+	if err != nil {
+		return err
+	}
 
-		err = newCredentialsFile.Save()
-		if err != nil {
-			return err
-		}
+	longTermCredentials, err := findBackedUpCredentials(backup, profileName)
+	if err != nil {
+		return err
+	}
 
-		fmt.Println("Restored original credentials from backup")
+	err = credentialsFile.MergeCredentialsIntoProfile(longTermCredentials, profileName)
+	if err != nil {
+		return err
+	}
 
-		return backup.Delete()
+	err = c.save(credentialsFile)
+	if err != nil {
+		return err
 	}
 
-	return errors.New("unable to find original credentials")
+	fmt.Printf("Restored long-term credentials of '%s' profile from backup\n", profileName)
+
+	return c.removeBackup(backup, profileName)
 }
 
 func (c *Coordinator) RestorePermanentCredentialsIfAppropriate() {
@@ -60,7 +68,7 @@ func (c *Coordinator) RestorePermanentCredentialsIfAppropriate() {
 	// As a convenience to the user, we'll detect this scenario and attempt to restore a backup if one exists.
 	// Since this behavior isn't part of the critical path, we'll return silently rather than elevate errors.
 
-	if c.Env.DoesHaveCredentialsFile() {
+	if c.hasCredentialsFile() {
 		credentialsFile, err := c.getCredentialsFile()
 		if err != nil {
 			return
@@ -69,8 +77,8 @@ func (c *Coordinator) RestorePermanentCredentialsIfAppropriate() {
 		areCredentialsTemporary := false == credentialsFile.DoesProfileHavePermanentCredentials(c.SelectedProfileName)
 		willCredentialsFileBeUsed := false == environment.WillEnvironmentVariablesPreemptUseOfCredentialsFile()
 
-		if areCredentialsTemporary && willCredentialsFileBeUsed && c.Env.DoesHaveCredentialsFileBackup() {
-			_ = c.Restore()
+		if areCredentialsTemporary && willCredentialsFileBeUsed && c.hasCredentialsFileBackup() {
+			_ = c.restoreProfile(c.SelectedProfileName)
 		}
 	}
 }
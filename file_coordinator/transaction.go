@@ -0,0 +1,116 @@
+package file_coordinator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/luhring/awsmfa/credentials_file"
+	"os"
+)
+
+// transaction makes a login all-or-nothing. The credentials file and its backup are snapshotted when it begins,
+// and new session credentials are staged in memory until it's committed. If anything fails, Rollback puts the files
+// back the way they were.
+type transaction struct {
+	snapshots []fileSnapshot
+	staged    *credentials_file.CredentialsFile // nil until session credentials are saved
+}
+
+type fileSnapshot struct {
+	filename string
+	content  []byte // nil if the file didn't exist
+}
+
+// Begin starts a transaction covering the credentials file and its backup.
+// Restoring and backing up still modify the files on disk (the AWS SDK reads the credentials file from disk),
+// but those changes are undone by Rollback.
+func (c *Coordinator) Begin() error {
+	if c.transaction != nil {
+		return errors.New("a transaction is already in progress")
+	}
+
+	t := &transaction{}
+
+	for _, filename := range []string{c.Env.PathToCredentialsFile(), c.Env.PathToCredentialsFileBackup()} {
+		content, err := c.files().ReadFile(filename)
+		if err != nil && false == os.IsNotExist(err) {
+			return fmt.Errorf("unable to read %s: %s", filename, err.Error())
+		}
+
+		t.snapshots = append(t.snapshots, fileSnapshot{filename: filename, content: content})
+	}
+
+	c.transaction = t
+
+	return nil
+}
+
+// Commit writes the staged session credentials to the credentials file and ends the transaction.
+// If writing fails, the transaction remains in progress, so that it can be rolled back.
+func (c *Coordinator) Commit() error {
+	if c.transaction == nil {
+		return nil
+	}
+
+	if c.transaction.staged != nil {
+		err := c.save(c.transaction.staged)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.transaction = nil
+
+	return nil
+}
+
+// Rollback discards staged session credentials and returns the credentials file and its backup
+// to their state when the transaction began.
+func (c *Coordinator) Rollback() error {
+	t := c.transaction
+	if t == nil {
+		return nil
+	}
+
+	c.transaction = nil
+	didChangeFiles := false
+
+	for _, snapshot := range t.snapshots {
+		currentContent, err := c.files().ReadFile(snapshot.filename)
+		doesExist := err == nil
+
+		if err != nil && false == os.IsNotExist(err) {
+			return err
+		}
+
+		if snapshot.content == nil {
+			if doesExist {
+				err = c.files().Remove(snapshot.filename)
+				if err != nil {
+					return err
+				}
+
+				didChangeFiles = true
+			}
+
+			continue
+		}
+
+		if doesExist && bytes.Equal(currentContent, snapshot.content) {
+			continue
+		}
+
+		err = c.files().WriteFile(snapshot.filename, snapshot.content)
+		if err != nil {
+			return err
+		}
+
+		didChangeFiles = true
+	}
+
+	if didChangeFiles {
+		fmt.Println("Rolled back changes to credentials file")
+	}
+
+	return nil
+}
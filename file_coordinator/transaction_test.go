@@ -0,0 +1,219 @@
+package file_coordinator
+
+import (
+	"errors"
+	"github.com/luhring/awsmfa/credentials"
+	"github.com/luhring/awsmfa/environment"
+	"os"
+	"testing"
+	"time"
+)
+
+// fakeFileSystem keeps files in memory. Writes to filenames in failingWrites fail.
+type fakeFileSystem struct {
+	files         map[string]string
+	failingWrites map[string]bool
+}
+
+func (fs *fakeFileSystem) ReadFile(filename string) ([]byte, error) {
+	content, ok := fs.files[filename]
+	if false == ok {
+		return nil, &os.PathError{Op: "open", Path: filename, Err: os.ErrNotExist}
+	}
+
+	return []byte(content), nil
+}
+
+func (fs *fakeFileSystem) WriteFile(filename string, content []byte) error {
+	if fs.failingWrites[filename] {
+		return errors.New("disk full")
+	}
+
+	fs.files[filename] = string(content)
+
+	return nil
+}
+
+func (fs *fakeFileSystem) Remove(filename string) error {
+	if _, ok := fs.files[filename]; false == ok {
+		return &os.PathError{Op: "remove", Path: filename, Err: os.ErrNotExist}
+	}
+
+	delete(fs.files, filename)
+
+	return nil
+}
+
+func (fs *fakeFileSystem) Exists(filename string) bool {
+	_, ok := fs.files[filename]
+	return ok
+}
+
+const (
+	permanentCredentialsFile = "[default]\naws_access_key_id = AKIAPERMANENT\naws_secret_access_key = permanent-secret\n"
+	temporaryCredentialsFile = "[default]\naws_access_key_id = ASIAOLD\naws_secret_access_key = old-secret\naws_session_token = old-token\n"
+)
+
+func newTestCoordinator(files map[string]string) (*Coordinator, *fakeFileSystem) {
+	fs := &fakeFileSystem{
+		files:         files,
+		failingWrites: map[string]bool{},
+	}
+
+	c := &Coordinator{
+		Env:                 &environment.Environment{},
+		SelectedProfileName: "default",
+		TargetProfileName:   "default",
+		fs:                  fs,
+	}
+
+	return c, fs
+}
+
+func TestTransaction(t *testing.T) {
+	env := &environment.Environment{}
+	credentialsPath := env.PathToCredentialsFile()
+	backupPath := env.PathToCredentialsFileBackup()
+	sessionCredentials := credentials.NewWithExpiration("ASIANEW", "new-secret", "new-token", time.Date(2019, 1, 1, 18, 0, 0, 0, time.UTC))
+
+	t.Run("commit saves staged credentials", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{credentialsPath: permanentCredentialsFile})
+
+		err := c.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.BackUpPermanentCredentialsIfPresent()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.SaveCredentials(sessionCredentials, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fs.files[credentialsPath] != permanentCredentialsFile {
+			t.Error("expected credentials file to remain unchanged until commit")
+		}
+
+		err = c.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedContent := "[default]\naws_access_key_id = ASIANEW\naws_secret_access_key = new-secret\naws_session_token = new-token\nx_security_token_expires = 2019-01-01T18:00:00Z\n"
+
+		if fs.files[credentialsPath] != expectedContent {
+			t.Errorf("expected credentials file:\n%s\nbut got:\n%s", expectedContent, fs.files[credentialsPath])
+		}
+
+		if fs.files[backupPath] != backupFileHeader+"\n"+permanentCredentialsFile {
+			t.Error("expected backup of permanent credentials")
+		}
+	})
+
+	t.Run("rollback after failed authentication removes new backup", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{credentialsPath: permanentCredentialsFile})
+
+		err := c.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.BackUpPermanentCredentialsIfPresent()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// STS rejects the MFA token here, so no credentials are saved.
+
+		err = c.Rollback()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fs.files[credentialsPath] != permanentCredentialsFile {
+			t.Error("expected credentials file to be unchanged")
+		}
+
+		if fs.Exists(backupPath) {
+			t.Error("expected backup created during the transaction to be removed")
+		}
+	})
+
+	t.Run("rollback undoes restore from backup", func(t *testing.T) {
+		if environment.WillEnvironmentVariablesPreemptUseOfCredentialsFile() {
+			t.Skip("permanent credentials aren't restored when AWS environment variables are set")
+		}
+
+		c, fs := newTestCoordinator(map[string]string{
+			credentialsPath: temporaryCredentialsFile,
+			backupPath:      permanentCredentialsFile,
+		})
+
+		err := c.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c.RestorePermanentCredentialsIfAppropriate()
+
+		if fs.files[credentialsPath] != permanentCredentialsFile || fs.Exists(backupPath) {
+			t.Fatal("expected permanent credentials to be restored from backup")
+		}
+
+		err = c.Rollback()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fs.files[credentialsPath] != temporaryCredentialsFile {
+			t.Error("expected credentials file to be returned to its original content")
+		}
+
+		if fs.files[backupPath] != permanentCredentialsFile {
+			t.Error("expected backup to be returned to its original content")
+		}
+	})
+
+	t.Run("failed commit can be rolled back", func(t *testing.T) {
+		c, fs := newTestCoordinator(map[string]string{credentialsPath: permanentCredentialsFile})
+
+		err := c.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.BackUpPermanentCredentialsIfPresent()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.SaveCredentials(sessionCredentials, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fs.failingWrites[credentialsPath] = true
+
+		err = c.Commit()
+		if err == nil {
+			t.Fatal("expected commit to fail")
+		}
+
+		err = c.Rollback()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fs.files[credentialsPath] != permanentCredentialsFile {
+			t.Error("expected credentials file to be unchanged")
+		}
+
+		if fs.Exists(backupPath) {
+			t.Error("expected backup created during the transaction to be removed")
+		}
+	})
+}
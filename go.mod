@@ -1,8 +1,14 @@
 module github.com/luhring/awsmfa
 
+go 1.27.1
+
 require (
 	github.com/aws/aws-sdk-go v1.16.9
 	github.com/go-ini/ini v1.39.3
+)
+
+require (
+	github.com/jmespath/go-jmespath v0.0.0-20180206201540-c2b33e8439af // indirect
 	github.com/jtolds/gls v4.2.1+incompatible // indirect
 	github.com/smartystreets/assertions v0.0.0-20180927180507-b2de0cb4f26d // indirect
 	github.com/smartystreets/goconvey v0.0.0-20181108003508-044398e4856c // indirect
@@ -1,16 +1,21 @@
 package main
 
-import "fmt"
+import (
+	"flag"
+	"fmt"
+)
 
 func displayHelpText() {
-	const helpText = `Syntax: awsmfa [commands] [mfa-token]
+	const header = `Syntax: awsmfa [command] [options] [arguments]
 
 Commands:
 
--h, --help          Show this help text
--r, --restore       Restore original credentials back to AWS credentials file
+`
+
+	const footer = `
+Type 'awsmfa <command> --help' to see the command's options. Without a command, awsmfa runs 'login', e.g. 'awsmfa 123456'.
 
-'mfa-token' must be the currently displayed numeric MFA token from the device you've configured as a virtual MFA device associated with your IAM user. In addition, active IAM access credentials must already have been stored in your local 'credentials' file or in the AWS-specific environment variables. For help with enabling a virtual MFA device, see https://docs.aws.amazon.com/IAM/latest/UserGuide/id_credentials_mfa_enable_virtual.html.
+'mfa-token' must be the currently displayed numeric MFA token from the MFA device associated with your IAM user. If it's omitted, awsmfa prompts for it on the terminal without displaying it. In addition, active IAM access credentials must already have been stored in your local 'credentials' file or in the AWS-specific environment variables. For help with enabling a virtual MFA device, see https://docs.aws.amazon.com/IAM/latest/UserGuide/id_credentials_mfa_enable_virtual.html.
 
 Examples:
 
@@ -19,6 +24,26 @@ To obtain temporary session credentials from AWS and save to credentials file:
 $ awsmfa 123456
 [You can now perform AWS actions that require MFA...]
 
+To keep long-term credentials in the 'work' profile and save session credentials to the 'work-mfa' profile:
+
+$ awsmfa --profile work --target-profile work-mfa 123456
+
+To assume a role using MFA and save the role's credentials to credentials file:
+
+$ awsmfa --role-arn arn:aws:iam::111111111111:role/Admin 123456
+
+To run a command with temporary credentials, without modifying the credentials file:
+
+$ awsmfa exec --profile prod 123456 -- terraform apply
+
+To set temporary credentials as environment variables in the current shell:
+
+$ eval "$(awsmfa env 123456)"
+
+To check whether the profile currently holds session credentials:
+
+$ awsmfa status
+
 To switch back to using permanent access credentials:
 
 $ awsmfa --restore
@@ -27,5 +52,36 @@ $ awsmfa --restore
 For more information: https://github.com/luhring/awsmfa
 
 `
-	fmt.Print(helpText)
+	fmt.Print(header)
+
+	for _, cmd := range commands() {
+		fmt.Printf("%-19s %s\n", cmd.name, cmd.description)
+	}
+
+	fmt.Print(footer)
+}
+
+// displayCommandHelpText shows the syntax and options of a single command.
+func displayCommandHelpText(cmd *command) {
+	syntax := "awsmfa " + cmd.name
+
+	if len(cmd.flagGroups) > 0 {
+		syntax += " [options]"
+	}
+
+	if len(cmd.arguments) > 0 {
+		syntax += " " + cmd.arguments
+	}
+
+	fmt.Printf("Syntax: %s\n\n%s\n\nOptions:\n\n", syntax, cmd.description)
+
+	newFlagSet(cmd, &options{}).VisitAll(func(f *flag.Flag) {
+		if f.Name == "h" || f.Name == "help" {
+			return
+		}
+
+		fmt.Printf("%-19s %s\n", "--"+f.Name, f.Usage)
+	})
+
+	fmt.Printf("%-19s %s\n", "-h, --help", "Show this help text")
 }
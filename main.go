@@ -3,12 +3,12 @@ package main
 import (
 	"errors"
 	"fmt"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/luhring/awsmfa/authenticator"
 	"github.com/luhring/awsmfa/environment"
 	"github.com/luhring/awsmfa/file_coordinator"
+	"github.com/luhring/awsmfa/terminal"
 	"os"
+	"time"
 )
 
 var (
@@ -18,45 +18,167 @@ var (
 )
 
 func main() {
-	numberOfArgumentsPassedIn := len(os.Args) - 1
-	errUnexpectedArguments := errors.New("unexpected argument(s) passed in, type 'awsmfa --help' to see correct syntax")
+	cmd, opts, isExplicit, err := parseCommandLine(os.Args[1:])
+	if err != nil {
+		helpCommand := "awsmfa --help"
+		if isExplicit {
+			helpCommand = fmt.Sprintf("awsmfa %s --help", cmd.name)
+		}
+
+		exitWithError(fmt.Errorf("%s, type '%s' to see correct syntax", err.Error(), helpCommand))
+	}
 
-	const profileName = "default"
+	if opts.shouldShowHelp {
+		if isExplicit {
+			displayCommandHelpText(cmd)
+		} else {
+			displayHelpText()
+		}
 
-	if numberOfArgumentsPassedIn == 0 {
-		displayHelpText()
 		os.Exit(0)
 	}
 
-	if numberOfArgumentsPassedIn == 1 {
-		if os.Args[1] == "--help" || os.Args[1] == "-h" {
-			help()
+	cmd.run(opts)
+}
+
+func login(opts *options) {
+	env := environment.MustInit()
+	fileCoordinator := newFileCoordinator(env, opts)
+	request := newLoginRequestFromArguments(env, opts)
+
+	authenticate(fileCoordinator, request)
+}
+
+func restore(opts *options) {
+	fileCoordinator := newFileCoordinator(environment.MustInit(), opts)
+
+	err := fileCoordinator.Lock(file_coordinator.DefaultLockTimeout)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	err = fileCoordinator.Restore()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	os.Exit(0)
+}
+
+func status(opts *options) {
+	env := environment.MustInit()
+
+	// AWS is only contacted to verify the credentials, so settings that determine how it's contacted are only needed then.
+	var settings *awsSettings
+	var err error
+
+	if opts.shouldVerify {
+		settings, err = resolveAWSSettings(opts, loadConfigFile(env))
+		if err != nil {
+			exitWithError(err)
 		}
+	}
+
+	s := getSessionStatus(newFileCoordinator(env, opts), settings)
 
-		env := environment.MustInit()
-		fileCoordinator, err := file_coordinator.New(env, profileName)
+	if opts.shouldOutputJSON {
+		err = s.writeJSON(os.Stdout)
 		if err != nil {
 			exitWithError(err)
 		}
+	} else {
+		s.writeText(os.Stdout)
+	}
+
+	os.Exit(0)
+}
+
+func credentialProcess(opts *options) {
+	env := environment.MustInit()
+	request := newLoginRequestFromArguments(env, opts)
+
+	c, err := getCachedOrNewCredentials(env, request, opts.profileName, newFileCoordinator(env, opts).SourceProfileName())
+	if err != nil {
+		exitWithError(err)
+	}
+
+	err = writeCredentialProcessOutput(os.Stdout, c)
+	if err != nil {
+		exitWithError(err)
+	}
 
-		if os.Args[1] == "--restore" || os.Args[1] == "-r" {
-			restore(fileCoordinator)
+	os.Exit(0)
+}
+
+func execCommand(opts *options) {
+	env := environment.MustInit()
+	request := newLoginRequestFromArguments(env, opts)
+
+	c, err := getCachedOrNewCredentials(env, request, opts.profileName, newFileCoordinator(env, opts).SourceProfileName())
+	if err != nil {
+		exitWithError(err)
+	}
+
+	exitCode, err := runWithCredentials(opts.commandArguments, c)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	os.Exit(exitCode)
+}
+
+func printEnv(opts *options) {
+	err := validateShell(opts.shell)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if opts.shouldUnset {
+		if len(opts.arguments) != 0 {
+			exitWithError(errors.New("don't specify an MFA token with --unset"))
+		}
+
+		err = writeUnsetStatements(os.Stdout, opts.shell)
+		if err != nil {
+			exitWithError(err)
 		}
 
-		mfaToken := os.Args[1]
-		authenticate(fileCoordinator, mfaToken)
+		os.Exit(0)
+	}
+
+	env := environment.MustInit()
+	request := newLoginRequestFromArguments(env, opts)
+
+	c, err := getCachedOrNewCredentials(env, request, opts.profileName, newFileCoordinator(env, opts).SourceProfileName())
+	if err != nil {
+		exitWithError(err)
+	}
+
+	err = writeExportStatements(os.Stdout, opts.shell, c)
+	if err != nil {
+		exitWithError(err)
 	}
 
-	exitWithError(errUnexpectedArguments)
+	os.Exit(0)
 }
 
-func help() {
-	displayHelpText()
+func showVersion(opts *options) {
+	v := getVersionInfo()
+
+	if opts.shouldOutputJSON {
+		err := v.writeJSON(os.Stdout)
+		if err != nil {
+			exitWithError(err)
+		}
+	} else {
+		v.writeText(os.Stdout)
+	}
+
 	os.Exit(0)
 }
 
-func restore(fileCoordinator *file_coordinator.Coordinator) {
-	err := fileCoordinator.Restore()
+func completion(opts *options) {
+	err := writeCompletionScript(os.Stdout, opts.arguments[0], commands())
 	if err != nil {
 		exitWithError(err)
 	}
@@ -64,36 +186,185 @@ func restore(fileCoordinator *file_coordinator.Coordinator) {
 	os.Exit(0)
 }
 
-func authenticate(fileCoordinator *file_coordinator.Coordinator, mfaToken string) {
-	err := authenticator.ValidateMFATokenFormat(mfaToken)
+func newFileCoordinator(env *environment.Environment, opts *options) *file_coordinator.Coordinator {
+	fileCoordinator, err := file_coordinator.New(env, opts.profileName, opts.targetProfileName)
 	if err != nil {
-		exitWithError(fmt.Errorf("unexpected argument passed in (%s)", err.Error()))
+		exitWithError(err)
 	}
 
-	fileCoordinator.RestorePermanentCredentialsIfAppropriate()
+	fileCoordinator.Layout, err = resolveLayout(opts, loadConfigFile(env))
+	if err != nil {
+		exitWithError(err)
+	}
+
+	err = fileCoordinator.ValidateLayout()
+	if err != nil {
+		exitWithError(err)
+	}
+
+	return fileCoordinator
+}
+
+// newLoginRequestFromArguments resolves the login request using the MFA token given as the command's argument (if any).
+func newLoginRequestFromArguments(env *environment.Environment, opts *options) *loginRequest {
+	tokenArgument := ""
+	if len(opts.arguments) == 1 {
+		tokenArgument = opts.arguments[0]
+	}
+
+	mfaToken, err := resolveMFAToken(opts, os.Stdin, tokenArgument)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	request, err := newLoginRequest(opts, loadConfigFile(env), mfaToken)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	return request
+}
+
+func authenticate(fileCoordinator *file_coordinator.Coordinator, request *loginRequest) {
+	if len(request.mfaToken) != 0 {
+		err := authenticator.ValidateMFATokenFormat(request.mfaToken)
+		if err != nil {
+			exitWithError(fmt.Errorf("unexpected argument passed in (%s)", err.Error()))
+		}
+	}
+
+	err := authenticator.ValidateSessionDuration(request.sessionDuration, request.strategy)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	err = authenticator.ValidateRoleChain(request.roleChain, request.strategy, fileCoordinator.ProfileNamesInUse()...)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	if request.roleChain != nil {
+		var linkProfileNames []string
+		for _, link := range request.roleChain.Links {
+			linkProfileNames = append(linkProfileNames, link.ProfileName)
+		}
+
+		err = fileCoordinator.CheckForLongTermCredentials(linkProfileNames...)
+		if err != nil {
+			exitWithError(fmt.Errorf("unable to save the role chain's credentials: %s", err.Error()))
+		}
+	}
+
+	if false == request.shouldForce && canReuseExistingSession(fileCoordinator, request) {
+		os.Exit(0)
+	}
 
-	err = fileCoordinator.BackUpPermanentCredentialsIfPresent()
+	// The terminal (if any) is closed when the process exits.
+	mfaToken, tty, err := obtainMFAToken(fileCoordinator.Env, request, fileCoordinator.SelectedProfileName)
 	if err != nil {
 		exitWithError(err)
 	}
 
-	awsSession := session.Must(session.NewSession())
-	stsClient := sts.New(awsSession)
+	request.mfaToken = mfaToken
+
+	// From here on, the credentials file is read, backed up and saved, so concurrent runs must wait their turn.
+	// The lock is released when the process exits.
+	err = fileCoordinator.Lock(file_coordinator.DefaultLockTimeout)
+	if err != nil {
+		exitWithError(err)
+	}
 
-	auth, err := authenticator.New(stsClient, fileCoordinator)
+	err = fileCoordinator.Begin()
 	if err != nil {
 		exitWithError(err)
 	}
 
-	err = auth.AuthenticateUsingMFA(mfaToken)
+	err = authenticateInTransaction(fileCoordinator, request, tty)
 	if err != nil {
+		rollbackErr := fileCoordinator.Rollback()
+		if rollbackErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "unable to roll back changes to credentials file: %s\n", rollbackErr.Error())
+		}
+
 		exitWithError(err)
 	}
 
 	os.Exit(0)
 }
 
+// authenticateInTransaction performs the steps of a login that modify the credentials file.
+// The file coordinator's transaction is committed once the new session credentials are saved, so any error means it should be rolled back.
+// If tty is non-nil, it's used for prompts such as selecting one of several MFA devices.
+func authenticateInTransaction(fileCoordinator *file_coordinator.Coordinator, request *loginRequest, tty *terminal.Terminal) error {
+	err := fileCoordinator.PrepareLongTermCredentials()
+	if err != nil {
+		return err
+	}
+
+	awsSession := newAWSSession(fileCoordinator.SourceProfileName(), request.aws)
+
+	auth, err := authenticator.New(newSTSClient(awsSession, request.aws), newIAMClient(awsSession), fileCoordinator)
+	if err != nil {
+		return err
+	}
+
+	if tty != nil {
+		auth.UsePrompt(tty, tty)
+	}
+
+	if len(request.serialNumber) != 0 {
+		auth.UseMFADevice(request.serialNumber)
+	}
+
+	return auth.AuthenticateUsingMFA(request.strategy, request.mfaToken, request.sessionDuration, request.roleChain)
+}
+
+// canReuseExistingSession reports whether the target profile (and every profile of the role chain) already holds session credentials
+// that were obtained the same way (e.g. for the same role, using the same profile) and remain valid long enough.
+func canReuseExistingSession(fileCoordinator *file_coordinator.Coordinator, request *loginRequest) bool {
+	profileNames := []string{fileCoordinator.TargetProfileName}
+
+	if request.roleChain != nil {
+		for _, link := range request.roleChain.Links {
+			profileNames = append(profileNames, link.ProfileName)
+		}
+	}
+
+	sources := authenticator.SessionSources(request.strategy, request.roleChain, fileCoordinator.SelectedProfileName, fileCoordinator.TargetProfileName)
+
+	now := time.Now()
+	var earliestExpiration time.Time
+
+	for _, profileName := range profileNames {
+		sessionCredentials, ok := fileCoordinator.FindReusableSession(profileName, sources[profileName], request.minimumRemaining, now)
+		if false == ok {
+			return false
+		}
+
+		if request.shouldVerify {
+			_, err := lookUpIdentity(sessionCredentials, request.aws)
+			if err != nil {
+				return false
+			}
+		}
+
+		if earliestExpiration.IsZero() || sessionCredentials.Expiration.Before(earliestExpiration) {
+			earliestExpiration = sessionCredentials.Expiration
+		}
+	}
+
+	fmt.Printf("Session credentials in '%s' profile remain valid until %s (%s remaining), so there's no need to authenticate again\n", fileCoordinator.TargetProfileName, earliestExpiration.Local().Format(time.RFC1123), earliestExpiration.Sub(now).Round(time.Second))
+	fmt.Println("Use --force to obtain new session credentials anyway")
+
+	return true
+}
+
 func exitWithError(err error) {
 	_, _ = fmt.Fprintln(os.Stderr, err.Error())
+
+	if authErr, ok := err.(authenticator.Error); ok {
+		os.Exit(authErr.ExitCode())
+	}
+
 	os.Exit(1)
 }
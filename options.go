@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+const (
+	defaultProfileName      = "default"
+	defaultMinimumRemaining = 15 * time.Minute
+	defaultShell            = "bash"
+)
+
+type options struct {
+	shouldShowHelp    bool
+	profileName       string
+	targetProfileName string
+	sessionDuration   time.Duration // zero if not specified
+	roleARN           string
+	roleSessionName   string
+	externalID        string
+	serialNumber      string
+	region            string
+	stsEndpoint       string
+	shouldOutputJSON  bool
+	shouldForce       bool
+	shouldVerify      bool
+	minimumRemaining  time.Duration
+	shell             string
+	shouldUnset       bool
+	shouldPrompt      bool
+	shouldReadStdin   bool
+	totpSeedFile      string
+	totpSeedVariable  string
+	totpSeedCommand   string
+	layout            string
+	arguments         []string // positional arguments following the command's name, e.g. the MFA token
+	commandArguments  []string // arguments following "--", e.g. the command to run for 'awsmfa exec'
+}
+
+// parseCommandLine determines the command to run and parses its options.
+// For compatibility with earlier versions of awsmfa, 'awsmfa 123456' is an alias for 'awsmfa login 123456',
+// 'awsmfa --restore' (or '-r') is an alias for 'awsmfa restore', and 'awsmfa --version' is an alias for 'awsmfa version'.
+// isExplicit reports whether the command was named, as opposed to implied by an alias.
+func parseCommandLine(args []string) (cmd *command, opts *options, isExplicit bool, err error) {
+	var commandArguments []string
+
+	for i, arg := range args {
+		if arg == "--" {
+			commandArguments = args[i+1:]
+			args = args[:i]
+			break
+		}
+	}
+
+	cmd, args, isExplicit = selectCommand(args)
+
+	opts, err = parseOptions(cmd, args)
+	if err != nil {
+		return cmd, nil, isExplicit, err
+	}
+
+	opts.commandArguments = commandArguments
+
+	if opts.shouldShowHelp {
+		return cmd, opts, isExplicit, nil
+	}
+
+	if false == isExplicit {
+		for _, arg := range opts.arguments {
+			if findCommand(arg) != nil {
+				return cmd, nil, isExplicit, fmt.Errorf("the command must be the first argument (e.g. 'awsmfa %s --profile work')", arg)
+			}
+		}
+	}
+
+	if len(opts.arguments) < cmd.minimumArguments || len(opts.arguments) > cmd.maximumArguments {
+		return cmd, nil, isExplicit, errors.New("unexpected argument(s) passed in")
+	}
+
+	if cmd.requiresCommandArguments && len(commandArguments) == 0 {
+		return cmd, nil, isExplicit, errors.New("no command to run was specified after '--'")
+	}
+
+	if false == cmd.requiresCommandArguments && len(commandArguments) > 0 {
+		return cmd, nil, isExplicit, errors.New("unexpected argument(s) passed in after '--'")
+	}
+
+	return cmd, opts, isExplicit, nil
+}
+
+func selectCommand(args []string) (*command, []string, bool) {
+	if len(args) > 0 {
+		if cmd := findCommand(args[0]); cmd != nil {
+			return cmd, args[1:], true
+		}
+	}
+
+	for i, arg := range args {
+		if name, ok := commandForAliasFlag(arg); ok {
+			remainingArgs := append(append([]string{}, args[:i]...), args[i+1:]...)
+			return findCommand(name), remainingArgs, false
+		}
+	}
+
+	return findCommand(commandLogin), args, false
+}
+
+// commandForAliasFlag returns the name of the command for which arg is an alias, e.g. 'restore' for '--restore'.
+func commandForAliasFlag(arg string) (string, bool) {
+	if false == strings.HasPrefix(arg, "-") {
+		return "", false
+	}
+
+	switch strings.TrimLeft(arg, "-") {
+	case "r", "restore":
+		return commandRestore, true
+	case "version":
+		return commandVersion, true
+	default:
+		return "", false
+	}
+}
+
+func parseOptions(cmd *command, args []string) (*options, error) {
+	o := &options{}
+	flagSet := newFlagSet(cmd, o)
+
+	// The flag package stops at the first positional argument, so we resume parsing after each one.
+	// This allows flags to appear after the MFA token (e.g. 'awsmfa 123456 --profile work').
+
+	for {
+		err := flagSet.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		if flagSet.NArg() == 0 {
+			break
+		}
+
+		o.arguments = append(o.arguments, flagSet.Arg(0))
+		args = flagSet.Args()[1:]
+	}
+
+	if o.sessionDuration == 0 && wasFlagSet(flagSet, "duration") {
+		return nil, errors.New("duration must be greater than zero")
+	}
+
+	if o.minimumRemaining < 0 {
+		return nil, errors.New("min-remaining must not be negative")
+	}
+
+	if len(o.targetProfileName) == 0 {
+		o.targetProfileName = o.profileName
+	}
+
+	return o, nil
+}
+
+// newFlagSet returns a flag set with the flags that apply to the command, which are stored in o when parsed.
+func newFlagSet(cmd *command, o *options) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("awsmfa "+cmd.name, flag.ContinueOnError)
+	flagSet.SetOutput(ioutil.Discard)
+
+	flagSet.BoolVar(&o.shouldShowHelp, "help", false, "Show this help text")
+	flagSet.BoolVar(&o.shouldShowHelp, "h", false, "Show this help text")
+
+	for _, addFlags := range cmd.flagGroups {
+		addFlags(flagSet, o)
+	}
+
+	return flagSet
+}
+
+// Groups of flags shared by several commands
+
+func addProfileFlag(f *flag.FlagSet, o *options) {
+	f.StringVar(&o.profileName, "profile", defaultProfileName, "Name of the profile in the credentials file that holds your long-term credentials (default: \"default\")")
+}
+
+func addTargetProfileFlag(f *flag.FlagSet, o *options) {
+	f.StringVar(&o.targetProfileName, "target-profile", "", "Name of the profile to which the temporary session credentials are saved (default: same as --profile)")
+}
+
+func addAWSFlags(f *flag.FlagSet, o *options) {
+	f.StringVar(&o.region, "region", "", "AWS region whose STS endpoint is used (default: AWS_REGION, AWS_DEFAULT_REGION, the profile's 'region' setting, or us-east-1)")
+	f.StringVar(&o.stsEndpoint, "sts-endpoint", "", "URL of the STS endpoint to use (default: the region's regional STS endpoint)")
+}
+
+func addCredentialFlags(f *flag.FlagSet, o *options) {
+	f.DurationVar(&o.sessionDuration, "duration", 0, "Duration of the session, between 15m and 36h (12h when assuming a role), e.g. 90m or 12h (default: 6h, or 1h when assuming a role)")
+	f.StringVar(&o.roleARN, "role-arn", "", "ARN of a role to assume using MFA, instead of obtaining session credentials for your IAM user")
+	f.StringVar(&o.roleSessionName, "role-session-name", "", "Name of the role session (default: generated)")
+	f.StringVar(&o.externalID, "external-id", "", "External ID to provide when assuming the role")
+	f.StringVar(&o.serialNumber, "serial-number", "", "Serial number (or ARN, for virtual devices) of your MFA device (default: the profile's 'mfa_serial' setting, or discovered automatically)")
+	f.BoolVar(&o.shouldForce, "force", false, "Obtain new session credentials even if the existing session credentials are still valid")
+	f.DurationVar(&o.minimumRemaining, "min-remaining", defaultMinimumRemaining, "Reuse existing session credentials only if they remain valid for longer than this (default: 15m)")
+	f.BoolVar(&o.shouldPrompt, "prompt", false, "Prompt for the MFA token on the terminal (default when no mfa-token is given)")
+	f.BoolVar(&o.shouldReadStdin, "token-stdin", false, "Read the MFA token from stdin, e.g. when piping it from a password manager")
+	f.StringVar(&o.totpSeedFile, "totp-seed-file", "", "Generate the MFA token from the base32 seed of a virtual MFA device, read from this file")
+	f.StringVar(&o.totpSeedVariable, "totp-seed-env", "", "Generate the MFA token from the seed in this environment variable")
+	f.StringVar(&o.totpSeedCommand, "totp-seed-command", "", "Generate the MFA token from the seed printed by this command (e.g. a secret store's CLI)")
+}
+
+func addLayoutFlag(f *flag.FlagSet, o *options) {
+	f.StringVar(&o.layout, "layout", "", "Where long-term credentials are kept while the profile holds session credentials: backup-file or long-term-profile (default: the profile's 'awsmfa_layout' setting, or backup-file)")
+}
+
+func addVerifyFlag(f *flag.FlagSet, o *options) {
+	f.BoolVar(&o.shouldVerify, "verify", false, "Before reusing session credentials, confirm with AWS that they still work")
+}
+
+func addStatusVerifyFlag(f *flag.FlagSet, o *options) {
+	f.BoolVar(&o.shouldVerify, "verify", false, "Confirm with AWS that the credentials work, and show the identity they belong to")
+}
+
+func addJSONFlag(f *flag.FlagSet, o *options) {
+	f.BoolVar(&o.shouldOutputJSON, "json", false, "Print machine-readable JSON output")
+}
+
+func addShellFlags(f *flag.FlagSet, o *options) {
+	f.StringVar(&o.shell, "shell", defaultShell, "Shell for which statements are printed: bash, zsh, fish or powershell (default: bash)")
+	f.BoolVar(&o.shouldUnset, "unset", false, "Print statements that remove the credential environment variables instead")
+}
+
+func wasFlagSet(flagSet *flag.FlagSet, name string) bool {
+	wasSet := false
+
+	flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			wasSet = true
+		}
+	})
+
+	return wasSet
+}
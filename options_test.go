@@ -0,0 +1,214 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	testCases := []struct {
+		args               []string
+		expectedCommand    string
+		expectedIsExplicit bool
+		expectedOutput     *options
+	}{
+		{
+			args:            []string{"123456"},
+			expectedCommand: commandLogin,
+			expectedOutput: &options{
+				profileName:       "default",
+				targetProfileName: "default",
+				minimumRemaining:  15 * time.Minute,
+				arguments:         []string{"123456"},
+			},
+		},
+		{
+			args:            []string{},
+			expectedCommand: commandLogin,
+			expectedOutput: &options{
+				profileName:       "default",
+				targetProfileName: "default",
+				minimumRemaining:  15 * time.Minute,
+			},
+		},
+		{
+			args:            []string{"--profile", "work", "123456"},
+			expectedCommand: commandLogin,
+			expectedOutput: &options{
+				profileName:       "work",
+				targetProfileName: "work",
+				minimumRemaining:  15 * time.Minute,
+				arguments:         []string{"123456"},
+			},
+		},
+		{
+			args:               []string{"login", "123456", "--profile", "work", "--target-profile", "work-mfa"},
+			expectedCommand:    commandLogin,
+			expectedIsExplicit: true,
+			expectedOutput: &options{
+				profileName:       "work",
+				targetProfileName: "work-mfa",
+				minimumRemaining:  15 * time.Minute,
+				arguments:         []string{"123456"},
+			},
+		},
+		{
+			args:            []string{"--duration", "90m", "123456"},
+			expectedCommand: commandLogin,
+			expectedOutput: &options{
+				profileName:       "default",
+				targetProfileName: "default",
+				minimumRemaining:  15 * time.Minute,
+				sessionDuration:   90 * time.Minute,
+				arguments:         []string{"123456"},
+			},
+		},
+		{
+			args:            []string{"--force", "--min-remaining", "30m", "123456"},
+			expectedCommand: commandLogin,
+			expectedOutput: &options{
+				profileName:       "default",
+				targetProfileName: "default",
+				shouldForce:       true,
+				minimumRemaining:  30 * time.Minute,
+				arguments:         []string{"123456"},
+			},
+		},
+		{
+			args:               []string{"exec", "--profile", "prod", "123456", "--", "terraform", "apply", "--auto-approve"},
+			expectedCommand:    commandExec,
+			expectedIsExplicit: true,
+			expectedOutput: &options{
+				profileName:       "prod",
+				targetProfileName: "prod",
+				minimumRemaining:  15 * time.Minute,
+				arguments:         []string{"123456"},
+				commandArguments:  []string{"terraform", "apply", "--auto-approve"},
+			},
+		},
+		{
+			args:               []string{"env", "--shell", "fish", "--unset"},
+			expectedCommand:    commandEnv,
+			expectedIsExplicit: true,
+			expectedOutput: &options{
+				profileName:       "default",
+				targetProfileName: "default",
+				minimumRemaining:  15 * time.Minute,
+				shell:             "fish",
+				shouldUnset:       true,
+			},
+		},
+		{
+			args:               []string{"status", "--json"},
+			expectedCommand:    commandStatus,
+			expectedIsExplicit: true,
+			expectedOutput: &options{
+				profileName:       "default",
+				targetProfileName: "default",
+				shouldOutputJSON:  true,
+			},
+		},
+		{
+			args:               []string{"restore", "--profile", "work", "--layout", "long-term-profile"},
+			expectedCommand:    commandRestore,
+			expectedIsExplicit: true,
+			expectedOutput: &options{
+				profileName:       "work",
+				targetProfileName: "work",
+				layout:            "long-term-profile",
+			},
+		},
+		{
+			args:            []string{"-r", "--profile=work"},
+			expectedCommand: commandRestore,
+			expectedOutput: &options{
+				profileName:       "work",
+				targetProfileName: "work",
+			},
+		},
+		{
+			args:            []string{"--restore", "--profile", "work", "--target-profile", "work-mfa"},
+			expectedCommand: commandRestore,
+			expectedOutput: &options{
+				profileName:       "work",
+				targetProfileName: "work-mfa",
+			},
+		},
+		{
+			args:               []string{"restore", "--help"},
+			expectedCommand:    commandRestore,
+			expectedIsExplicit: true,
+			expectedOutput: &options{
+				shouldShowHelp:    true,
+				profileName:       "default",
+				targetProfileName: "default",
+			},
+		},
+		{
+			args:            []string{"--help"},
+			expectedCommand: commandLogin,
+			expectedOutput: &options{
+				shouldShowHelp:    true,
+				profileName:       "default",
+				targetProfileName: "default",
+				minimumRemaining:  15 * time.Minute,
+			},
+		},
+		{
+			args:            []string{"--version", "--json"},
+			expectedCommand: commandVersion,
+			expectedOutput: &options{
+				shouldOutputJSON: true,
+			},
+		},
+		{
+			args:               []string{"completion", "bash"},
+			expectedCommand:    commandCompletion,
+			expectedIsExplicit: true,
+			expectedOutput: &options{
+				arguments: []string{"bash"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		cmd, output, isExplicit, err := parseCommandLine(testCase.args)
+
+		if err != nil {
+			t.Errorf("unexpected error: %v -- args were %v", err, testCase.args)
+			continue
+		}
+
+		if cmd.name != testCase.expectedCommand || isExplicit != testCase.expectedIsExplicit {
+			t.Errorf("expected command %s (explicit: %t) but got %s (explicit: %t) -- args were %v", testCase.expectedCommand, testCase.expectedIsExplicit, cmd.name, isExplicit, testCase.args)
+		}
+
+		if false == reflect.DeepEqual(output, testCase.expectedOutput) {
+			t.Errorf("expected %+v but got %+v -- args were %v", testCase.expectedOutput, output, testCase.args)
+		}
+	}
+}
+
+func TestParseCommandLineWithInvalidArguments(t *testing.T) {
+	testCases := [][]string{
+		{"--unknown", "123456"},
+		{"--duration", "12", "123456"},
+		{"--duration", "0s", "123456"},
+		{"123456", "654321"},
+		{"--profile", "work", "status"},
+		{"status", "--duration", "1h"},
+		{"restore", "123456"},
+		{"exec", "123456"},
+		{"env", "--", "terraform"},
+		{"completion"},
+	}
+
+	for _, args := range testCases {
+		_, _, _, err := parseCommandLine(args)
+
+		if err == nil {
+			t.Errorf("expected an error -- args were %v", args)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/luhring/awsmfa/authenticator"
+	"github.com/luhring/awsmfa/environment"
+	"github.com/luhring/awsmfa/terminal"
+	"io"
+	"strings"
+)
+
+// Number of times the user can enter a malformed MFA token before awsmfa gives up
+const maximumTokenPromptAttempts = 3
+
+// secretPrompter is the part of terminal.Terminal used to prompt for MFA tokens.
+type secretPrompter interface {
+	PromptSecret(prompt string) (string, error)
+	Write(p []byte) (int, error)
+}
+
+// resolveMFAToken returns the MFA token given as an argument or on stdin (with --token-stdin).
+// An empty token means that one should be generated from the TOTP seed, or that the user should be prompted for one on the terminal, if one is needed.
+func resolveMFAToken(opts *options, stdin io.Reader, tokenArgument string) (string, error) {
+	if usesTOTPSeed(opts) && (len(tokenArgument) != 0 || opts.shouldPrompt || opts.shouldReadStdin) {
+		return "", errors.New("a TOTP seed can't be combined with --prompt, --token-stdin or an MFA token argument")
+	}
+
+	if opts.shouldReadStdin {
+		if len(tokenArgument) != 0 || opts.shouldPrompt {
+			return "", errors.New("--token-stdin can't be combined with --prompt or an MFA token argument")
+		}
+
+		return readMFATokenFromStdin(stdin)
+	}
+
+	if opts.shouldPrompt && len(tokenArgument) != 0 {
+		return "", errors.New("--prompt can't be combined with an MFA token argument")
+	}
+
+	return tokenArgument, nil
+}
+
+// obtainMFAToken returns the request's MFA token, generating it from the TOTP seed or prompting for it on the terminal if it wasn't given.
+// The terminal is only required for prompting for an MFA token, but when available,
+// it's also returned for other prompts, such as selecting one of several MFA devices. The caller should close it if it's non-nil.
+func obtainMFAToken(env *environment.Environment, request *loginRequest, profileName string) (string, *terminal.Terminal, error) {
+	mfaToken := request.mfaToken
+	var err error
+
+	if request.totpSeedSource != nil {
+		mfaToken, err = generateTOTPToken(env, request.totpSeedSource)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	tty, err := terminal.Open()
+	if err != nil && len(mfaToken) == 0 {
+		return "", nil, err
+	}
+
+	if len(mfaToken) == 0 {
+		mfaToken, err = promptForMFAToken(tty, profileName)
+	} else {
+		err = authenticator.ValidateMFATokenFormat(mfaToken)
+	}
+
+	if err != nil {
+		if tty != nil {
+			_ = tty.Close()
+		}
+
+		return "", nil, err
+	}
+
+	return mfaToken, tty, nil
+}
+
+func readMFATokenFromStdin(stdin io.Reader) (string, error) {
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("unable to read MFA token from stdin: %s", err.Error())
+	}
+
+	mfaToken := strings.TrimSpace(line)
+
+	if len(mfaToken) == 0 {
+		return "", errors.New("no MFA token was provided on stdin")
+	}
+
+	err = authenticator.ValidateMFATokenFormat(mfaToken)
+	if err != nil {
+		return "", err
+	}
+
+	return mfaToken, nil
+}
+
+// promptForMFAToken prompts for an MFA token until the user enters one with a valid format.
+func promptForMFAToken(p secretPrompter, profileName string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		mfaToken, err := p.PromptSecret(fmt.Sprintf("Enter MFA token for profile '%s': ", profileName))
+		if err != nil {
+			return "", err
+		}
+
+		err = authenticator.ValidateMFATokenFormat(mfaToken)
+		if err == nil {
+			return mfaToken, nil
+		}
+
+		if attempt == maximumTokenPromptAttempts {
+			return "", err
+		}
+
+		_, _ = fmt.Fprintf(p, "%s, please try again\n", err.Error())
+	}
+}
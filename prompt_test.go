@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"github.com/luhring/awsmfa/authenticator"
+	"strings"
+	"testing"
+)
+
+type fakePrompter struct {
+	responses []string
+	prompts   int
+	output    bytes.Buffer
+}
+
+func (p *fakePrompter) PromptSecret(prompt string) (string, error) {
+	if p.prompts == len(p.responses) {
+		return "", errors.New("no more responses")
+	}
+
+	response := p.responses[p.prompts]
+	p.prompts++
+
+	return response, nil
+}
+
+func (p *fakePrompter) Write(b []byte) (int, error) {
+	return p.output.Write(b)
+}
+
+func TestPromptForMFAToken(t *testing.T) {
+	cases := []struct {
+		name            string
+		responses       []string
+		expectedToken   string
+		expectedError   string
+		expectedPrompts int
+	}{
+		{
+			name:            "valid token",
+			responses:       []string{"123456"},
+			expectedToken:   "123456",
+			expectedPrompts: 1,
+		},
+		{
+			name:            "re-prompts after malformed tokens",
+			responses:       []string{"12345", "abcdef", "123456"},
+			expectedToken:   "123456",
+			expectedPrompts: 3,
+		},
+		{
+			name:            "gives up after too many malformed tokens",
+			responses:       []string{"1", "2", "3", "123456"},
+			expectedError:   authenticator.ErrMFATokenIncorrectLength,
+			expectedPrompts: maximumTokenPromptAttempts,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &fakePrompter{responses: tc.responses}
+
+			token, err := promptForMFAToken(p, "default")
+
+			if len(tc.expectedError) != 0 {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Errorf("expected error '%s' but got %v", tc.expectedError, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if token != tc.expectedToken {
+				t.Errorf("expected token '%s' but got '%s'", tc.expectedToken, token)
+			}
+
+			if p.prompts != tc.expectedPrompts {
+				t.Errorf("expected %d prompts but got %d", tc.expectedPrompts, p.prompts)
+			}
+		})
+	}
+}
+
+func TestResolveMFAToken(t *testing.T) {
+	cases := []struct {
+		name          string
+		opts          *options
+		stdin         string
+		tokenArgument string
+		expectedToken string
+		expectError   bool
+	}{
+		{
+			name:          "token argument",
+			opts:          &options{},
+			tokenArgument: "123456",
+			expectedToken: "123456",
+		},
+		{
+			name:          "no token means prompt",
+			opts:          &options{},
+			expectedToken: "",
+		},
+		{
+			name:          "token on stdin",
+			opts:          &options{shouldReadStdin: true},
+			stdin:         "654321\n",
+			expectedToken: "654321",
+		},
+		{
+			name:        "malformed token on stdin",
+			opts:        &options{shouldReadStdin: true},
+			stdin:       "65432\n",
+			expectError: true,
+		},
+		{
+			name:        "empty stdin",
+			opts:        &options{shouldReadStdin: true},
+			expectError: true,
+		},
+		{
+			name:          "token-stdin with token argument",
+			opts:          &options{shouldReadStdin: true},
+			stdin:         "654321\n",
+			tokenArgument: "123456",
+			expectError:   true,
+		},
+		{
+			name:          "TOTP seed with token argument",
+			opts:          &options{totpSeedFile: "/home/tony/.aws/seed"},
+			tokenArgument: "123456",
+			expectError:   true,
+		},
+		{
+			name:          "prompt with token argument",
+			opts:          &options{shouldPrompt: true},
+			tokenArgument: "123456",
+			expectError:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := resolveMFAToken(tc.opts, strings.NewReader(tc.stdin), tc.tokenArgument)
+
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error: %t, but got: %v", tc.expectError, err)
+			}
+
+			if token != tc.expectedToken {
+				t.Errorf("expected token '%s' but got '%s'", tc.expectedToken, token)
+			}
+		})
+	}
+}
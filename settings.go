@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"github.com/luhring/awsmfa/authenticator"
+	"github.com/luhring/awsmfa/config_file"
+	"github.com/luhring/awsmfa/environment"
+	"github.com/luhring/awsmfa/file_coordinator"
+	"github.com/luhring/awsmfa/totp"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Names of awsmfa-specific settings that can be stored in a profile's section of the AWS config file
+const (
+	configKeyForSessionDuration   = "awsmfa_duration"
+	configKeyForRoleChain         = "awsmfa_role_chain"
+	configKeyForRoleChainDuration = "awsmfa_role_chain_duration"
+	configKeyForSTSEndpoint       = "awsmfa_sts_endpoint"
+	configKeyForLayout            = "awsmfa_layout"
+)
+
+// Names of settings shared with the AWS CLI that awsmfa reads from a profile's section of the AWS config file
+const (
+	configKeyForRoleARN               = "role_arn"
+	configKeyForRoleSessionName       = "role_session_name"
+	configKeyForExternalID            = "external_id"
+	configKeyForRoleDurationInSeconds = "duration_seconds"
+	configKeyForMFASerial             = "mfa_serial"
+	configKeyForRegion                = "region"
+)
+
+// Names of environment variables that AWS tools use to determine the region
+const (
+	nameOfVariableForRegion        = "AWS_REGION"
+	nameOfVariableForDefaultRegion = "AWS_DEFAULT_REGION"
+)
+
+// loginRequest holds everything needed to obtain temporary credentials, as resolved from options and the config file.
+type loginRequest struct {
+	strategy        authenticator.Strategy
+	mfaToken        string
+	serialNumber    string
+	sessionDuration time.Duration
+	roleChain       *authenticator.RoleChain
+	aws             *awsSettings
+	totpSeedSource  totp.SeedSource // if non-nil, the MFA token is generated from this seed
+
+	// Existing session credentials are reused unless forced, or unless they expire within minimumRemaining.
+	shouldForce      bool
+	shouldVerify     bool
+	minimumRemaining time.Duration
+}
+
+func newLoginRequest(opts *options, configFile *config_file.ConfigFile, mfaToken string) (*loginRequest, error) {
+	settings, err := resolveAWSSettings(opts, configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := resolveStrategy(opts, configFile)
+
+	sessionDuration, err := resolveSessionDuration(opts, configFile, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	roleChain, err := resolveRoleChain(opts, configFile, newSTSClientFactory(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	totpSeedSource, err := resolveTOTPSeedSource(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loginRequest{
+		strategy:        strategy,
+		mfaToken:        mfaToken,
+		serialNumber:    resolveMFADeviceSerialNumber(opts, configFile),
+		sessionDuration: sessionDuration,
+		roleChain:       roleChain,
+		aws:             settings,
+		totpSeedSource:  totpSeedSource,
+
+		shouldForce:      opts.shouldForce,
+		shouldVerify:     opts.shouldVerify,
+		minimumRemaining: opts.minimumRemaining,
+	}, nil
+}
+
+func loadConfigFile(env *environment.Environment) *config_file.ConfigFile {
+	if false == env.DoesHaveConfigFile() {
+		return config_file.NewEmpty()
+	}
+
+	configFile, err := config_file.NewFromDisk(env.PathToConfigFile())
+	if err != nil {
+		exitWithError(fmt.Errorf("unable to load config file (%s): %s", env.PathToConfigFile(), err.Error()))
+	}
+
+	return configFile
+}
+
+func resolveStrategy(opts *options, configFile *config_file.ConfigFile) authenticator.Strategy {
+	roleARN := firstNonEmpty(opts.roleARN, configFile.GetProfileValue(opts.profileName, configKeyForRoleARN))
+
+	if len(roleARN) == 0 {
+		return &authenticator.SessionTokenStrategy{}
+	}
+
+	return &authenticator.AssumeRoleStrategy{
+		RoleARN:         roleARN,
+		RoleSessionName: firstNonEmpty(opts.roleSessionName, configFile.GetProfileValue(opts.profileName, configKeyForRoleSessionName)),
+		ExternalID:      firstNonEmpty(opts.externalID, configFile.GetProfileValue(opts.profileName, configKeyForExternalID)),
+	}
+}
+
+func resolveSessionDuration(opts *options, configFile *config_file.ConfigFile, strategy authenticator.Strategy) (time.Duration, error) {
+	if opts.sessionDuration != 0 {
+		return opts.sessionDuration, nil
+	}
+
+	configuredValue := configFile.GetProfileValue(opts.profileName, configKeyForSessionDuration)
+
+	if len(configuredValue) != 0 {
+		sessionDuration, err := time.ParseDuration(configuredValue)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse '%s' setting of profile '%s' in config file: %s", configKeyForSessionDuration, opts.profileName, err.Error())
+		}
+
+		return sessionDuration, nil
+	}
+
+	if _, isAssumingRole := strategy.(*authenticator.AssumeRoleStrategy); isAssumingRole {
+		configuredValue := configFile.GetProfileValue(opts.profileName, configKeyForRoleDurationInSeconds)
+
+		if len(configuredValue) != 0 {
+			durationInSeconds, err := strconv.ParseInt(configuredValue, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("unable to parse '%s' setting of profile '%s' in config file: %s", configKeyForRoleDurationInSeconds, opts.profileName, err.Error())
+			}
+
+			return time.Duration(durationInSeconds) * time.Second, nil
+		}
+	}
+
+	return strategy.DefaultSessionDuration(), nil
+}
+
+// resolveLayout determines where the profile's long-term credentials are kept, from --layout or the profile's 'awsmfa_layout' setting.
+func resolveLayout(opts *options, configFile *config_file.ConfigFile) (file_coordinator.Layout, error) {
+	if len(opts.layout) != 0 {
+		return file_coordinator.ParseLayout(opts.layout)
+	}
+
+	layout, err := file_coordinator.ParseLayout(configFile.GetProfileValue(opts.profileName, configKeyForLayout))
+	if err != nil {
+		return "", fmt.Errorf("unable to parse '%s' setting of profile '%s' in config file: %s", configKeyForLayout, opts.profileName, err.Error())
+	}
+
+	return layout, nil
+}
+
+// resolveMFADeviceSerialNumber returns the MFA device's serial number if the user specified one, or an empty string if it should be discovered.
+func resolveMFADeviceSerialNumber(opts *options, configFile *config_file.ConfigFile) string {
+	return firstNonEmpty(opts.serialNumber, configFile.GetProfileValue(opts.profileName, configKeyForMFASerial))
+}
+
+// resolveRoleChain returns the profile's role chain, or nil if the profile doesn't have one.
+func resolveRoleChain(opts *options, configFile *config_file.ConfigFile, newSTSClient authenticator.STSClientFactory) (*authenticator.RoleChain, error) {
+	configuredValue := configFile.GetProfileValue(opts.profileName, configKeyForRoleChain)
+
+	if len(configuredValue) == 0 {
+		return nil, nil
+	}
+
+	links, err := authenticator.ParseRoleChainLinks(configuredValue)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse '%s' setting of profile '%s' in config file: %s", configKeyForRoleChain, opts.profileName, err.Error())
+	}
+
+	sessionDuration := authenticator.MaximumChainedRoleSessionDuration
+	configuredDuration := configFile.GetProfileValue(opts.profileName, configKeyForRoleChainDuration)
+
+	if len(configuredDuration) != 0 {
+		sessionDuration, err = time.ParseDuration(configuredDuration)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse '%s' setting of profile '%s' in config file: %s", configKeyForRoleChainDuration, opts.profileName, err.Error())
+		}
+	}
+
+	return &authenticator.RoleChain{
+		Links:           links,
+		SessionDuration: sessionDuration,
+		NewSTSClient:    newSTSClient,
+	}, nil
+}
+
+// resolveAWSSettings determines the region and STS endpoint.
+// The region is taken from --region, the AWS region environment variables, or the profile's 'region' setting, in that order.
+// Unless an STS endpoint is specified, the region's regional STS endpoint is used.
+func resolveAWSSettings(opts *options, configFile *config_file.ConfigFile) (*awsSettings, error) {
+	region := firstNonEmpty(
+		opts.region,
+		os.Getenv(nameOfVariableForRegion),
+		os.Getenv(nameOfVariableForDefaultRegion),
+		configFile.GetProfileValue(opts.profileName, configKeyForRegion),
+		defaultRegion,
+	)
+
+	stsEndpoint := firstNonEmpty(opts.stsEndpoint, configFile.GetProfileValue(opts.profileName, configKeyForSTSEndpoint))
+
+	if len(stsEndpoint) == 0 {
+		var err error
+
+		stsEndpoint, err = regionalSTSEndpoint(region)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &awsSettings{
+		region:      region,
+		stsEndpoint: stsEndpoint,
+	}, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if len(value) != 0 {
+			return value
+		}
+	}
+
+	return ""
+}
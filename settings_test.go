@@ -0,0 +1,212 @@
+package main
+
+import (
+	"github.com/go-ini/ini"
+	"github.com/luhring/awsmfa/authenticator"
+	"github.com/luhring/awsmfa/config_file"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestConfigFile(t *testing.T) *config_file.ConfigFile {
+	configuration, err := ini.Load([]byte(`[default]
+region = eu-west-1
+
+[profile work]
+awsmfa_duration = 12h
+awsmfa_sts_endpoint = https://sts.example.com
+mfa_serial = arn:aws:iam::123456789012:mfa/tony.stark
+
+[profile admin]
+role_arn = arn:aws:iam::111111111111:role/Admin
+role_session_name = tony.stark
+external_id = my-external-id
+duration_seconds = 7200
+
+[profile admin-with-duration]
+role_arn = arn:aws:iam::111111111111:role/Admin
+awsmfa_duration = 30m
+duration_seconds = 7200
+
+[profile invalid]
+awsmfa_duration = 12
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &config_file.ConfigFile{Configuration: configuration}
+}
+
+func TestResolveSessionDuration(t *testing.T) {
+	configFile := newTestConfigFile(t)
+	assumeRole := &authenticator.AssumeRoleStrategy{RoleARN: "arn:aws:iam::111111111111:role/Admin"}
+
+	testCases := []struct {
+		opts             *options
+		strategy         authenticator.Strategy
+		expectedDuration time.Duration
+		expectError      bool
+	}{
+		{&options{profileName: "work", sessionDuration: 90 * time.Minute}, &authenticator.SessionTokenStrategy{}, 90 * time.Minute, false},
+		{&options{profileName: "work"}, &authenticator.SessionTokenStrategy{}, 12 * time.Hour, false},
+		{&options{profileName: "default"}, &authenticator.SessionTokenStrategy{}, authenticator.DefaultSessionDuration, false},
+		{&options{profileName: "admin"}, assumeRole, 2 * time.Hour, false},
+		{&options{profileName: "admin-with-duration"}, assumeRole, 30 * time.Minute, false},
+		{&options{profileName: "default"}, assumeRole, authenticator.DefaultRoleSessionDuration, false},
+		{&options{profileName: "invalid"}, &authenticator.SessionTokenStrategy{}, 0, true},
+	}
+
+	for _, testCase := range testCases {
+		duration, err := resolveSessionDuration(testCase.opts, configFile, testCase.strategy)
+
+		if testCase.expectError != (err != nil) {
+			t.Errorf("profile '%s': expected error: %t but got %v", testCase.opts.profileName, testCase.expectError, err)
+			continue
+		}
+
+		if duration != testCase.expectedDuration {
+			t.Errorf("profile '%s': expected %s but got %s", testCase.opts.profileName, testCase.expectedDuration, duration)
+		}
+	}
+}
+
+func TestResolveStrategy(t *testing.T) {
+	configFile := newTestConfigFile(t)
+
+	testCases := []struct {
+		opts             *options
+		expectedStrategy authenticator.Strategy
+	}{
+		{
+			&options{profileName: "work"},
+			&authenticator.SessionTokenStrategy{},
+		},
+		{
+			&options{profileName: "admin"},
+			&authenticator.AssumeRoleStrategy{
+				RoleARN:         "arn:aws:iam::111111111111:role/Admin",
+				RoleSessionName: "tony.stark",
+				ExternalID:      "my-external-id",
+			},
+		},
+		{
+			&options{profileName: "admin", roleARN: "arn:aws:iam::222222222222:role/ReadOnly", externalID: "other-external-id"},
+			&authenticator.AssumeRoleStrategy{
+				RoleARN:         "arn:aws:iam::222222222222:role/ReadOnly",
+				RoleSessionName: "tony.stark",
+				ExternalID:      "other-external-id",
+			},
+		},
+		{
+			&options{profileName: "work", roleARN: "arn:aws:iam::222222222222:role/ReadOnly"},
+			&authenticator.AssumeRoleStrategy{
+				RoleARN: "arn:aws:iam::222222222222:role/ReadOnly",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		strategy := resolveStrategy(testCase.opts, configFile)
+
+		if false == reflect.DeepEqual(strategy, testCase.expectedStrategy) {
+			t.Errorf("expected %+v but got %+v -- options were %+v", testCase.expectedStrategy, strategy, testCase.opts)
+		}
+	}
+}
+
+func TestResolveMFADeviceSerialNumber(t *testing.T) {
+	configFile := newTestConfigFile(t)
+
+	testCases := []struct {
+		opts                 *options
+		expectedSerialNumber string
+	}{
+		{&options{profileName: "work"}, "arn:aws:iam::123456789012:mfa/tony.stark"},
+		{&options{profileName: "work", serialNumber: "GAHT12345678"}, "GAHT12345678"},
+		{&options{profileName: "default"}, ""},
+	}
+
+	for _, testCase := range testCases {
+		serialNumber := resolveMFADeviceSerialNumber(testCase.opts, configFile)
+
+		if serialNumber != testCase.expectedSerialNumber {
+			t.Errorf("expected '%s' but got '%s' -- options were %+v", testCase.expectedSerialNumber, serialNumber, testCase.opts)
+		}
+	}
+}
+
+func TestResolveAWSSettings(t *testing.T) {
+	configFile := newTestConfigFile(t)
+
+	originalRegion, hadRegion := os.LookupEnv(nameOfVariableForRegion)
+	originalDefaultRegion, hadDefaultRegion := os.LookupEnv(nameOfVariableForDefaultRegion)
+
+	defer func() {
+		setOrUnsetVariable(nameOfVariableForRegion, originalRegion, hadRegion)
+		setOrUnsetVariable(nameOfVariableForDefaultRegion, originalDefaultRegion, hadDefaultRegion)
+	}()
+
+	testCases := []struct {
+		opts             *options
+		region           string // AWS_REGION
+		defaultRegion    string // AWS_DEFAULT_REGION
+		expectedSettings *awsSettings
+	}{
+		{
+			opts:             &options{profileName: "default", region: "ap-southeast-2"},
+			region:           "us-west-2",
+			defaultRegion:    "us-east-2",
+			expectedSettings: &awsSettings{region: "ap-southeast-2", stsEndpoint: "https://sts.ap-southeast-2.amazonaws.com"},
+		},
+		{
+			opts:             &options{profileName: "default"},
+			region:           "us-west-2",
+			defaultRegion:    "us-east-2",
+			expectedSettings: &awsSettings{region: "us-west-2", stsEndpoint: "https://sts.us-west-2.amazonaws.com"},
+		},
+		{
+			opts:             &options{profileName: "default"},
+			defaultRegion:    "us-east-2",
+			expectedSettings: &awsSettings{region: "us-east-2", stsEndpoint: "https://sts.us-east-2.amazonaws.com"},
+		},
+		{
+			opts:             &options{profileName: "default"},
+			expectedSettings: &awsSettings{region: "eu-west-1", stsEndpoint: "https://sts.eu-west-1.amazonaws.com"},
+		},
+		{
+			opts:             &options{profileName: "work"},
+			expectedSettings: &awsSettings{region: defaultRegion, stsEndpoint: "https://sts.example.com"},
+		},
+		{
+			opts:             &options{profileName: "work", stsEndpoint: "https://sts.amazonaws.com"},
+			expectedSettings: &awsSettings{region: defaultRegion, stsEndpoint: "https://sts.amazonaws.com"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		setOrUnsetVariable(nameOfVariableForRegion, testCase.region, len(testCase.region) != 0)
+		setOrUnsetVariable(nameOfVariableForDefaultRegion, testCase.defaultRegion, len(testCase.defaultRegion) != 0)
+
+		settings, err := resolveAWSSettings(testCase.opts, configFile)
+		if err != nil {
+			t.Errorf("unexpected error: %v -- options were %+v", err, testCase.opts)
+			continue
+		}
+
+		if *settings != *testCase.expectedSettings {
+			t.Errorf("expected %+v but got %+v -- options were %+v", testCase.expectedSettings, settings, testCase.opts)
+		}
+	}
+}
+
+// setOrUnsetVariable sets the environment variable to value if wasSet is true, and otherwise unsets it.
+func setOrUnsetVariable(name, value string, wasSet bool) {
+	if wasSet {
+		_ = os.Setenv(name, value)
+	} else {
+		_ = os.Unsetenv(name)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/luhring/awsmfa/credentials"
+	"github.com/luhring/awsmfa/credentials_file"
+	"github.com/luhring/awsmfa/environment"
+	"github.com/luhring/awsmfa/file_coordinator"
+	"io"
+	"strings"
+	"time"
+)
+
+// Types of credentials that a profile can hold
+const (
+	credentialsTypeNone      = "none"
+	credentialsTypePermanent = "permanent"
+	credentialsTypeTemporary = "temporary"
+)
+
+// sessionStatus describes the current state of a profile's credentials.
+type sessionStatus struct {
+	Profile                                    string     `json:"profile"`
+	CredentialsType                            string     `json:"credentials_type"`
+	AccessKeyID                                string     `json:"access_key_id,omitempty"`
+	Identity                                   string     `json:"identity,omitempty"`
+	IdentityError                              string     `json:"identity_error,omitempty"`
+	Expiration                                 *time.Time `json:"expiration,omitempty"`
+	RemainingSeconds                           *int64     `json:"remaining_seconds,omitempty"`
+	HasBackup                                  bool       `json:"has_backup"`
+	BackupPath                                 string     `json:"backup_path,omitempty"`
+	BackupProfile                              string     `json:"backup_profile,omitempty"`
+	EnvironmentVariablesPreemptCredentialsFile bool       `json:"environment_variables_preempt_credentials_file"`
+}
+
+// getSessionStatus reads the target profile's credentials from the credentials file.
+// The identity they belong to is only looked up if settings is non-nil, since that requires a call to AWS.
+func getSessionStatus(fileCoordinator *file_coordinator.Coordinator, settings *awsSettings) *sessionStatus {
+	env := fileCoordinator.Env
+	profileName := fileCoordinator.TargetProfileName
+
+	s := &sessionStatus{
+		Profile:         profileName,
+		CredentialsType: credentialsTypeNone,
+		EnvironmentVariablesPreemptCredentialsFile: environment.WillEnvironmentVariablesPreemptUseOfCredentialsFile(),
+	}
+
+	s.setBackup(fileCoordinator)
+
+	if false == env.DoesHaveCredentialsFile() {
+		return s
+	}
+
+	credentialsFile, err := credentials_file.NewFromDisk(env.PathToCredentialsFile())
+	if err != nil {
+		return s
+	}
+
+	c, err := credentialsFile.GetCredentialsFromProfile(profileName)
+	if err != nil {
+		return s
+	}
+
+	s.AccessKeyID = c.AccessKeyID
+	s.CredentialsType = credentialsTypeTemporary
+
+	if c.HasExpiration() {
+		s.setExpiration(c, time.Now())
+	}
+
+	if c.ArePermanent() {
+		s.CredentialsType = credentialsTypePermanent
+	}
+
+	if settings != nil {
+		s.Identity, err = lookUpIdentity(c, settings)
+		if err != nil {
+			s.IdentityError = strings.Replace(err.Error(), "\n", " ", -1)
+		}
+	}
+
+	return s
+}
+
+// setBackup records where the target profile's long-term credentials are kept, if anywhere: in the layout's companion profile
+// of the credentials file, or in the backup file.
+func (s *sessionStatus) setBackup(fileCoordinator *file_coordinator.Coordinator) {
+	env := fileCoordinator.Env
+
+	if fileCoordinator.Layout == file_coordinator.LayoutLongTermProfile {
+		longTermProfileName := fileCoordinator.LongTermProfileName()
+
+		if doesFileHavePermanentCredentials(env.PathToCredentialsFile(), longTermProfileName) {
+			s.HasBackup = true
+			s.BackupPath = env.PathToCredentialsFile()
+			s.BackupProfile = longTermProfileName
+		}
+
+		return
+	}
+
+	if doesFileHavePermanentCredentials(env.PathToCredentialsFileBackup(), fileCoordinator.TargetProfileName) {
+		s.HasBackup = true
+		s.BackupPath = env.PathToCredentialsFileBackup()
+	}
+}
+
+func doesFileHavePermanentCredentials(path, profileName string) bool {
+	f, err := credentials_file.NewFromDisk(path)
+	if err != nil {
+		return false
+	}
+
+	return f.DoesProfileHavePermanentCredentials(profileName)
+}
+
+func lookUpIdentity(c *credentials.Credentials, settings *awsSettings) (string, error) {
+	stsClient := newSTSClientFactory(settings)(c)
+
+	callerIdentity, err := stsClient.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(callerIdentity.Arn), nil
+}
+
+func (s *sessionStatus) setExpiration(c *credentials.Credentials, now time.Time) {
+	expiration := c.Expiration
+	remainingSeconds := int64(c.RemainingLifetime(now) / time.Second)
+
+	s.Expiration = &expiration
+	s.RemainingSeconds = &remainingSeconds
+}
+
+func (s *sessionStatus) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(s)
+}
+
+func (s *sessionStatus) writeText(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Profile:      %s\n", s.Profile)
+
+	switch s.CredentialsType {
+	case credentialsTypeNone:
+		_, _ = fmt.Fprintln(w, "Credentials:  none found in credentials file")
+	default:
+		_, _ = fmt.Fprintf(w, "Credentials:  %s (%s)\n", s.CredentialsType, s.AccessKeyID)
+	}
+
+	if len(s.Identity) != 0 {
+		_, _ = fmt.Fprintf(w, "Identity:     %s\n", s.Identity)
+	} else if len(s.IdentityError) != 0 {
+		_, _ = fmt.Fprintf(w, "Identity:     unavailable (%s)\n", s.IdentityError)
+	}
+
+	if s.CredentialsType == credentialsTypeTemporary {
+		if s.Expiration == nil {
+			_, _ = fmt.Fprintln(w, "Expires:      unknown")
+		} else if *s.RemainingSeconds == 0 {
+			_, _ = fmt.Fprintf(w, "Expires:      %s (expired)\n", s.Expiration.Local().Format(time.RFC1123))
+		} else {
+			remaining := time.Duration(*s.RemainingSeconds) * time.Second
+			_, _ = fmt.Fprintf(w, "Expires:      %s (in %s)\n", s.Expiration.Local().Format(time.RFC1123), remaining)
+		}
+	}
+
+	if s.HasBackup && len(s.BackupProfile) != 0 {
+		_, _ = fmt.Fprintf(w, "Backup:       '%s' profile in %s\n", s.BackupProfile, s.BackupPath)
+	} else if s.HasBackup {
+		_, _ = fmt.Fprintf(w, "Backup:       %s\n", s.BackupPath)
+	} else {
+		_, _ = fmt.Fprintln(w, "Backup:       none")
+	}
+
+	if s.EnvironmentVariablesPreemptCredentialsFile {
+		_, _ = fmt.Fprintf(w, "\nWARNING: the environment variable '%s' is set, so most AWS tools will use the credentials from your environment variables instead of this profile.\n", environment.NameOfVariableForAccessKeyID)
+	}
+}
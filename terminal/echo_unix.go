@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package terminal
+
+import (
+	"os"
+	"os/exec"
+)
+
+// disableEcho stops the terminal from displaying what the user types, and returns a function that restores echo.
+// stty is used so that awsmfa doesn't depend on platform-specific terminal ioctls.
+func disableEcho(input *os.File) (func(), error) {
+	err := stty(input, "-echo")
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = stty(input, "echo")
+	}, nil
+}
+
+func stty(input *os.File, args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = input
+
+	return cmd.Run()
+}
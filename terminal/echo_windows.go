@@ -0,0 +1,44 @@
+//go:build windows
+// +build windows
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+)
+
+// Console input mode flag that causes typed characters to be displayed
+const enableEchoInput = 0x0004
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// disableEcho stops the console from displaying what the user types, and returns a function that restores echo.
+func disableEcho(input *os.File) (func(), error) {
+	handle := syscall.Handle(input.Fd())
+
+	var mode uint32
+
+	err := syscall.GetConsoleMode(handle, &mode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setConsoleMode(handle, mode&^enableEchoInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = setConsoleMode(handle, mode)
+	}, nil
+}
+
+func setConsoleMode(handle syscall.Handle, mode uint32) error {
+	result, _, err := procSetConsoleMode.Call(uintptr(handle), uintptr(mode))
+	if result == 0 {
+		return err
+	}
+
+	return nil
+}
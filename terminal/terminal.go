@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// Exit code used when the user interrupts a prompt, following the shell convention of 128 + SIGINT
+const exitCodeForInterrupt = 130
+
+// Terminal is the user's controlling terminal, which remains available for prompts when stdin and stdout are redirected
+// (e.g. when awsmfa runs as a credential_process, or its output is captured by a shell).
+type Terminal struct {
+	input  *os.File
+	output *os.File
+	reader *bufio.Reader
+}
+
+func Open() (*Terminal, error) {
+	input, err := os.Open(nameOfTerminalInput)
+	if err != nil {
+		return nil, errors.New("unable to open terminal for prompting, no terminal is available")
+	}
+
+	output, err := os.OpenFile(nameOfTerminalOutput, os.O_WRONLY, 0)
+	if err != nil {
+		_ = input.Close()
+		return nil, errors.New("unable to open terminal for prompting, no terminal is available")
+	}
+
+	return &Terminal{
+		input:  input,
+		output: output,
+		reader: bufio.NewReader(input),
+	}, nil
+}
+
+// Read and Write allow the terminal to be used for other prompts, such as selecting an MFA device.
+
+func (t *Terminal) Read(p []byte) (int, error) {
+	return t.reader.Read(p)
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.output.Write(p)
+}
+
+// Prompt displays the prompt and returns the line that the user types in response.
+func (t *Terminal) Prompt(prompt string) (string, error) {
+	_, _ = fmt.Fprint(t.output, prompt)
+
+	line, err := t.reader.ReadString('\n')
+	if err != nil && len(line) == 0 {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
+
+// PromptSecret is like Prompt, but doesn't display what the user types.
+// If echo can't be disabled (e.g. stty isn't available), the input is displayed as usual.
+func (t *Terminal) PromptSecret(prompt string) (string, error) {
+	restoreEcho, err := disableEcho(t.input)
+	if err != nil {
+		return t.Prompt(prompt)
+	}
+
+	// Without this, interrupting the prompt would leave the user's terminal without echo.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-interrupts:
+			restoreEcho()
+			_, _ = fmt.Fprintln(t.output)
+			os.Exit(exitCodeForInterrupt)
+		case <-done:
+		}
+	}()
+
+	line, err := t.Prompt(prompt)
+
+	signal.Stop(interrupts)
+	close(done)
+	restoreEcho()
+	_, _ = fmt.Fprintln(t.output) // the user's newline wasn't displayed
+
+	return line, err
+}
+
+func (t *Terminal) Close() error {
+	_ = t.output.Close()
+	return t.input.Close()
+}
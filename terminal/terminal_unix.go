@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package terminal
+
+const (
+	nameOfTerminalInput  = "/dev/tty"
+	nameOfTerminalOutput = "/dev/tty"
+)
@@ -0,0 +1,68 @@
+package totp
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// SeedSource provides the base32-encoded seed of a virtual MFA device, e.g. from a file or a secret store.
+type SeedSource interface {
+	Seed() (string, error)
+}
+
+// FileSeedSource reads the seed from a file.
+type FileSeedSource struct {
+	Path string
+}
+
+func (s *FileSeedSource) Seed() (string, error) {
+	content, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read TOTP seed file: %s", err.Error())
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// EnvironmentSeedSource reads the seed from an environment variable.
+type EnvironmentSeedSource struct {
+	VariableName string
+}
+
+func (s *EnvironmentSeedSource) Seed() (string, error) {
+	seed := os.Getenv(s.VariableName)
+
+	if len(seed) == 0 {
+		return "", fmt.Errorf("environment variable '%s' doesn't contain a TOTP seed", s.VariableName)
+	}
+
+	return seed, nil
+}
+
+// CommandSeedSource runs a command (e.g. a password manager's CLI) that prints the seed.
+// The command is split on whitespace and run directly, not by a shell.
+type CommandSeedSource struct {
+	Command string
+}
+
+func (s *CommandSeedSource) Seed() (string, error) {
+	fields := strings.Fields(s.Command)
+
+	if len(fields) == 0 {
+		return "", errors.New("no command was given for obtaining the TOTP seed")
+	}
+
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to obtain TOTP seed from command '%s': %s", fields[0], err.Error())
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
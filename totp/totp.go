@@ -0,0 +1,75 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Parameters used by AWS virtual MFA devices, which are the RFC 6238 defaults
+const (
+	Period = 30 * time.Second
+	Digits = 6
+)
+
+// DecodeSeed decodes a base32-encoded seed, as shown when setting up a virtual MFA device.
+// Spaces, lowercase letters and missing padding are tolerated.
+func DecodeSeed(encoded string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(encoded), ""))
+	normalized = strings.TrimRight(normalized, "=")
+
+	if len(normalized) == 0 {
+		return nil, errors.New("TOTP seed is empty")
+	}
+
+	seed, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil {
+		return nil, errors.New("TOTP seed isn't valid base32")
+	}
+
+	return seed, nil
+}
+
+// Counter returns the number of the time window that contains t.
+func Counter(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// StartOfWindow returns the time at which the counter's time window begins.
+func StartOfWindow(counter int64) time.Time {
+	return time.Unix(counter*int64(Period/time.Second), 0)
+}
+
+// Code computes the TOTP code for the counter's time window (RFC 4226, section 5.3).
+func Code(seed []byte, counter int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, seed)
+	_, _ = mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
+
+// NextUnusedCounter returns the counter whose code should be used at time now, given the counter of the last code used,
+// and how long to wait until that counter's time window begins. AWS rejects a code that has already been used.
+func NextUnusedCounter(lastUsedCounter int64, now time.Time) (int64, time.Duration) {
+	counter := Counter(now)
+
+	if counter > lastUsedCounter {
+		return counter, 0
+	}
+
+	next := lastUsedCounter + 1
+
+	return next, StartOfWindow(next).Sub(now)
+}
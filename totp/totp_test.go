@@ -0,0 +1,105 @@
+package totp
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCode(t *testing.T) {
+	// Test vectors from RFC 6238, appendix B (SHA-1), truncated to six digits
+	seed := []byte("12345678901234567890")
+
+	cases := []struct {
+		time         int64
+		expectedCode string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range cases {
+		code := Code(seed, Counter(time.Unix(tc.time, 0)))
+
+		if code != tc.expectedCode {
+			t.Errorf("at %d: expected code %s but got %s", tc.time, tc.expectedCode, code)
+		}
+	}
+}
+
+func TestDecodeSeed(t *testing.T) {
+	cases := []struct {
+		encoded     string
+		expectError bool
+	}{
+		{"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", false},
+		{"gezd gnbv gy3t qojq gezd gnbv gy3t qojq", false},
+		{"GEZDGNBVGY3TQOJQ!", true},
+		{"GEZDGNBV1", true},
+		{"", true},
+	}
+
+	for _, tc := range cases {
+		seed, err := DecodeSeed(tc.encoded)
+
+		if tc.expectError != (err != nil) {
+			t.Errorf("%q: expected error: %t, but got: %v", tc.encoded, tc.expectError, err)
+			continue
+		}
+
+		if err == nil && string(seed) != "12345678901234567890" {
+			t.Errorf("%q: unexpected seed %q", tc.encoded, seed)
+		}
+	}
+}
+
+func TestNextUnusedCounter(t *testing.T) {
+	now := time.Unix(1000000000, 0) // 10 seconds into the window with counter 33333333
+
+	cases := []struct {
+		name            string
+		lastUsedCounter int64
+		expectedCounter int64
+		expectedWait    time.Duration
+	}{
+		{"never used", 0, 33333333, 0},
+		{"previous code used", 33333332, 33333333, 0},
+		{"current code used", 33333333, 33333334, 20 * time.Second},
+	}
+
+	for _, tc := range cases {
+		counter, wait := NextUnusedCounter(tc.lastUsedCounter, now)
+
+		if counter != tc.expectedCounter || wait != tc.expectedWait {
+			t.Errorf("%s: expected (%d, %s) but got (%d, %s)", tc.name, tc.expectedCounter, tc.expectedWait, counter, wait)
+		}
+	}
+}
+
+func TestUsageRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "awsmfa-totp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := NewUsageRecord(dir, []byte("12345678901234567890"))
+
+	if r.LastUsedCounter() != 0 {
+		t.Errorf("expected no recorded counter")
+	}
+
+	err = r.Save(33333333)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.LastUsedCounter() != 33333333 {
+		t.Errorf("expected recorded counter 33333333 but got %d", r.LastUsedCounter())
+	}
+}
@@ -0,0 +1,51 @@
+package totp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// UsageRecord remembers the counter of the last code used for a seed, so that the same code isn't used twice,
+// even by separate runs of awsmfa.
+type UsageRecord struct {
+	Path string
+}
+
+// NewUsageRecord returns the usage record for the seed in the directory.
+// The record's file name is derived from a hash of the seed, so the seed itself isn't revealed.
+func NewUsageRecord(directory string, seed []byte) *UsageRecord {
+	hash := sha256.Sum256(seed)
+
+	return &UsageRecord{
+		Path: filepath.Join(directory, hex.EncodeToString(hash[:8])),
+	}
+}
+
+// LastUsedCounter returns the counter of the last code used, or zero if no code has been recorded.
+func (r *UsageRecord) LastUsedCounter() int64 {
+	content, err := ioutil.ReadFile(r.Path)
+	if err != nil {
+		return 0
+	}
+
+	counter, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return counter
+}
+
+func (r *UsageRecord) Save(counter int64) error {
+	err := os.MkdirAll(filepath.Dir(r.Path), 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.Path, []byte(strconv.FormatInt(counter, 10)+"\n"), 0600)
+}
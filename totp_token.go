@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/luhring/awsmfa/environment"
+	"github.com/luhring/awsmfa/totp"
+	"os"
+	"time"
+)
+
+func usesTOTPSeed(opts *options) bool {
+	return len(opts.totpSeedFile) != 0 || len(opts.totpSeedVariable) != 0 || len(opts.totpSeedCommand) != 0
+}
+
+// resolveTOTPSeedSource returns the source of the TOTP seed specified via options, or nil if none was specified.
+func resolveTOTPSeedSource(opts *options) (totp.SeedSource, error) {
+	var sources []totp.SeedSource
+
+	if len(opts.totpSeedFile) != 0 {
+		sources = append(sources, &totp.FileSeedSource{Path: opts.totpSeedFile})
+	}
+
+	if len(opts.totpSeedVariable) != 0 {
+		sources = append(sources, &totp.EnvironmentSeedSource{VariableName: opts.totpSeedVariable})
+	}
+
+	if len(opts.totpSeedCommand) != 0 {
+		sources = append(sources, &totp.CommandSeedSource{Command: opts.totpSeedCommand})
+	}
+
+	if len(sources) > 1 {
+		return nil, errors.New("only one of --totp-seed-file, --totp-seed-env and --totp-seed-command can be specified")
+	}
+
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	return sources[0], nil
+}
+
+// generateTOTPToken computes the MFA token from the seed.
+// If the current time window's code was already used, it waits for the next time window, since AWS rejects reused codes.
+func generateTOTPToken(env *environment.Environment, source totp.SeedSource) (string, error) {
+	encodedSeed, err := source.Seed()
+	if err != nil {
+		return "", err
+	}
+
+	seed, err := totp.DecodeSeed(encodedSeed)
+	if err != nil {
+		return "", err
+	}
+
+	record := totp.NewUsageRecord(env.PathToTOTPDirectory(), seed)
+	counter, wait := totp.NextUnusedCounter(record.LastUsedCounter(), time.Now())
+
+	if wait > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "The current MFA token was already used, waiting %s for the next one...\n", wait.Round(time.Second))
+		time.Sleep(wait)
+	}
+
+	err = record.Save(counter)
+	if err != nil {
+		return "", err
+	}
+
+	return totp.Code(seed, counter), nil
+}
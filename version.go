@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// versionInfo describes the build of awsmfa, so that bug reports can identify exactly which build is running.
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+func getVersionInfo() *versionInfo {
+	return &versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+func (v *versionInfo) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+func (v *versionInfo) writeText(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "awsmfa %s\n", v.Version)
+	_, _ = fmt.Fprintf(w, "Commit:      %s\n", v.Commit)
+	_, _ = fmt.Fprintf(w, "Build time:  %s\n", v.BuildTime)
+	_, _ = fmt.Fprintf(w, "Go version:  %s\n", v.GoVersion)
+	_, _ = fmt.Fprintf(w, "Platform:    %s\n", v.Platform)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVersionInfoOutput(t *testing.T) {
+	v := &versionInfo{
+		Version:   "1.2.0",
+		Commit:    "0d35a96",
+		BuildTime: "2019-01-14T18:04:05Z",
+		GoVersion: "go1.11.4",
+		Platform:  "darwin/amd64",
+	}
+
+	var text bytes.Buffer
+	v.writeText(&text)
+
+	expectedText := `awsmfa 1.2.0
+Commit:      0d35a96
+Build time:  2019-01-14T18:04:05Z
+Go version:  go1.11.4
+Platform:    darwin/amd64
+`
+
+	if text.String() != expectedText {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expectedText, text.String())
+	}
+
+	var jsonOutput bytes.Buffer
+
+	err := v.writeJSON(&jsonOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedJSON := `{
+  "version": "1.2.0",
+  "commit": "0d35a96",
+  "build_time": "2019-01-14T18:04:05Z",
+  "go_version": "go1.11.4",
+  "platform": "darwin/amd64"
+}
+`
+
+	if jsonOutput.String() != expectedJSON {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expectedJSON, jsonOutput.String())
+	}
+}